@@ -0,0 +1,263 @@
+// Package radix implements a compressed radix tree keyed by strings or byte
+// slices. Keys that share a prefix share the nodes for it, so prefix lookups
+// only walk as far as the prefix is long.
+package radix
+
+import (
+	"iter"
+	"sort"
+)
+
+type leaf struct {
+	key   string
+	value any
+}
+
+type node struct {
+	prefix   string  // the part of the key on the edge into this node
+	leaf     *leaf   // set when a key ends at this node
+	children []*node // ordered by the first byte of their prefix
+}
+
+// Tree maps keys to values. Unlike the trees package every key is stored at
+// most once, Insert replaces the value of a key that is already present. The
+// zero value is an empty tree ready to use.
+type Tree[K ~string | ~[]byte] struct {
+	root node
+	size int
+}
+
+func New[K ~string | ~[]byte]() *Tree[K] {
+	return &Tree[K]{}
+}
+
+// child returns the child whose prefix starts with c and where it sits, or
+// where it would have to be inserted
+func (n *node) child(c byte) (int, *node) {
+	idx := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= c
+	})
+	if idx < len(n.children) && n.children[idx].prefix[0] == c {
+		return idx, n.children[idx]
+	}
+	return idx, nil
+}
+
+func (n *node) addChild(idx int, child *node) {
+	n.children = append(n.children, nil)
+	copy(n.children[idx+1:], n.children[idx:])
+	n.children[idx] = child
+}
+
+func commonPrefix(a string, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func (t *Tree[K]) Insert(key K, value any) {
+	full := string(key)
+	n := &t.root
+	search := full
+	for {
+		if len(search) == 0 {
+			if n.leaf != nil {
+				n.leaf.value = value
+				return
+			}
+			n.leaf = &leaf{key: full, value: value}
+			t.size++
+			return
+		}
+
+		idx, child := n.child(search[0])
+		if child == nil {
+			n.addChild(idx, &node{
+				prefix: search,
+				leaf:   &leaf{key: full, value: value},
+			})
+			t.size++
+			return
+		}
+
+		common := commonPrefix(search, child.prefix)
+		if common == len(child.prefix) {
+			search = search[common:]
+			n = child
+			continue
+		}
+
+		// the key leaves the edge part way, split it where they differ
+		mid := &node{
+			prefix:   search[:common],
+			children: []*node{child},
+		}
+		child.prefix = child.prefix[common:]
+		n.children[idx] = mid
+
+		search = search[common:]
+		if len(search) == 0 {
+			mid.leaf = &leaf{key: full, value: value}
+		} else {
+			newIdx, _ := mid.child(search[0])
+			mid.addChild(newIdx, &node{
+				prefix: search,
+				leaf:   &leaf{key: full, value: value},
+			})
+		}
+		t.size++
+		return
+	}
+}
+
+func (t *Tree[K]) Get(key K) (any, bool) {
+	n := &t.root
+	search := string(key)
+	for {
+		if len(search) == 0 {
+			if n.leaf != nil {
+				return n.leaf.value, true
+			}
+			return nil, false
+		}
+		_, child := n.child(search[0])
+		if child == nil || len(search) < len(child.prefix) || search[:len(child.prefix)] != child.prefix {
+			return nil, false
+		}
+		search = search[len(child.prefix):]
+		n = child
+	}
+}
+
+func (t *Tree[K]) Delete(key K) bool {
+	var parent *node
+	n := &t.root
+	search := string(key)
+	for len(search) > 0 {
+		_, child := n.child(search[0])
+		if child == nil || len(search) < len(child.prefix) || search[:len(child.prefix)] != child.prefix {
+			return false
+		}
+		search = search[len(child.prefix):]
+		parent, n = n, child
+	}
+	if n.leaf == nil {
+		return false
+	}
+	n.leaf = nil
+	t.size--
+
+	// keep the tree compressed, a node without a key needs at least two
+	// children to be worth keeping
+	if parent == nil {
+		return true
+	}
+	switch len(n.children) {
+	case 0:
+		idx, _ := parent.child(n.prefix[0])
+		parent.children = append(parent.children[:idx], parent.children[idx+1:]...)
+		if parent != &t.root && parent.leaf == nil && len(parent.children) == 1 {
+			parent.mergeChild()
+		}
+	case 1:
+		n.mergeChild()
+	}
+	return true
+}
+
+// mergeChild folds the only child of n into n
+func (n *node) mergeChild() {
+	child := n.children[0]
+	n.prefix += child.prefix
+	n.leaf = child.leaf
+	n.children = child.children
+}
+
+// LongestPrefix returns the longest stored key that is a prefix of key
+func (t *Tree[K]) LongestPrefix(key K) (K, any, bool) {
+	var best *leaf
+	n := &t.root
+	search := string(key)
+	for {
+		if n.leaf != nil {
+			best = n.leaf
+		}
+		if len(search) == 0 {
+			break
+		}
+		_, child := n.child(search[0])
+		if child == nil || len(search) < len(child.prefix) || search[:len(child.prefix)] != child.prefix {
+			break
+		}
+		search = search[len(child.prefix):]
+		n = child
+	}
+
+	if best == nil {
+		var zero K
+		return zero, nil, false
+	}
+	return K(best.key), best.value, true
+}
+
+// WalkPrefix yields every entry whose key starts with prefix, in key order
+func (t *Tree[K]) WalkPrefix(prefix K) iter.Seq2[K, any] {
+	return func(yield func(K, any) bool) {
+		n := &t.root
+		search := string(prefix)
+		for len(search) > 0 {
+			_, child := n.child(search[0])
+			if child == nil {
+				return
+			}
+			common := commonPrefix(search, child.prefix)
+			if common == len(search) {
+				// the prefix ends inside this edge, everything below matches
+				walk(child, yield)
+				return
+			}
+			if common < len(child.prefix) {
+				return
+			}
+			search = search[common:]
+			n = child
+		}
+		walk(n, yield)
+	}
+}
+
+// All yields every entry in key order
+func (t *Tree[K]) All() iter.Seq2[K, any] {
+	return func(yield func(K, any) bool) {
+		walk(&t.root, yield)
+	}
+}
+
+// walk visits a node's own key before its children, which is key order
+// since a key sorts before every longer key it is a prefix of
+func walk[K ~string | ~[]byte](n *node, yield func(K, any) bool) bool {
+	if n.leaf != nil && !yield(K(n.leaf.key), n.leaf.value) {
+		return false
+	}
+	for _, child := range n.children {
+		if !walk(child, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tree[K]) Len() int {
+	return t.size
+}
+
+func (t *Tree[K]) IsEmpty() bool {
+	return t.size == 0
+}
+
+func (t *Tree[K]) Clear() {
+	t.root = node{}
+	t.size = 0
+}
@@ -0,0 +1,243 @@
+package radix
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTreeWithKeys(keys ...string) *Tree[string] {
+	t := New[string]()
+	for _, key := range keys {
+		t.Insert(key, len(key))
+	}
+	return t
+}
+
+func keysOf[K ~string | ~[]byte](seq func(func(K, any) bool)) []string {
+	var keys []string
+	for k := range seq {
+		keys = append(keys, string(k))
+	}
+	return keys
+}
+
+// checkTree verifies the tree stays compressed and its children ordered
+func checkTree(t *testing.T, tree *Tree[string]) {
+	t.Helper()
+	count := 0
+	var check func(n *node, isRoot bool)
+	check = func(n *node, isRoot bool) {
+		if n.leaf != nil {
+			count++
+		}
+		if !isRoot {
+			if n.prefix == "" {
+				t.Fatalf("non-root node with an empty prefix")
+			}
+			if n.leaf == nil && len(n.children) < 2 {
+				t.Fatalf("node %q without a key has %d children", n.prefix, len(n.children))
+			}
+		}
+		for i, child := range n.children {
+			if i > 0 && n.children[i-1].prefix[0] >= child.prefix[0] {
+				t.Fatalf("children of %q out of order", n.prefix)
+			}
+			check(child, false)
+		}
+	}
+	check(&tree.root, true)
+	if count != tree.Len() {
+		t.Fatalf("Len() = %d, but the tree holds %d keys", tree.Len(), count)
+	}
+}
+
+func TestTree_InsertAndGet(t *testing.T) {
+	tree := newTreeWithKeys("romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "rom", "")
+	checkTree(t, tree)
+
+	for _, key := range []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "rom", ""} {
+		val, found := tree.Get(key)
+		if !found || val != len(key) {
+			t.Errorf("Get(%q): expected %d, true, got %v, %v", key, len(key), val, found)
+		}
+	}
+	for _, key := range []string{"r", "ro", "roman", "rubicundusx", "x"} {
+		if _, found := tree.Get(key); found {
+			t.Errorf("Get(%q): expected found=false", key)
+		}
+	}
+	if tree.Len() != 9 {
+		t.Errorf("Len() = %d, want 9", tree.Len())
+	}
+
+	tree.Insert("romane", "replaced")
+	if val, _ := tree.Get("romane"); val != "replaced" {
+		t.Errorf("Insert of an existing key should replace its value, got %v", val)
+	}
+	if tree.Len() != 9 {
+		t.Errorf("Len() after replacing = %d, want 9", tree.Len())
+	}
+}
+
+func TestTree_Delete(t *testing.T) {
+	tree := newTreeWithKeys("romane", "romanus", "romulus", "rom", "rubens")
+
+	if tree.Delete("roman") {
+		t.Errorf("Delete(\"roman\") should report false for a missing key")
+	}
+	for _, key := range []string{"romanus", "rom", "romane"} {
+		if !tree.Delete(key) {
+			t.Errorf("Delete(%q) should report true", key)
+		}
+		if _, found := tree.Get(key); found {
+			t.Errorf("Get(%q) after Delete: expected found=false", key)
+		}
+		checkTree(t, tree)
+	}
+	if got := keysOf(tree.All()); !reflect.DeepEqual(got, []string{"romulus", "rubens"}) {
+		t.Errorf("All() after deletes got %v", got)
+	}
+
+	tree.Delete("romulus")
+	tree.Delete("rubens")
+	checkTree(t, tree)
+	if !tree.IsEmpty() || len(tree.root.children) != 0 {
+		t.Errorf("tree should be empty after deleting every key")
+	}
+}
+
+func TestTree_LongestPrefix(t *testing.T) {
+	tree := newTreeWithKeys("a", "abc", "abcdef", "b")
+
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{"abcde", "abc", true},
+		{"abcdefgh", "abcdef", true},
+		{"ab", "a", true},
+		{"a", "a", true},
+		{"bz", "b", true},
+		{"c", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		key, val, found := tree.LongestPrefix(tt.key)
+		if key != tt.want || found != tt.found {
+			t.Errorf("LongestPrefix(%q) = %q, %v, want %q, %v", tt.key, key, found, tt.want, tt.found)
+		}
+		if found && val != len(tt.want) {
+			t.Errorf("LongestPrefix(%q) value = %v, want %d", tt.key, val, len(tt.want))
+		}
+	}
+
+	tree.Insert("", 0)
+	if key, _, found := tree.LongestPrefix("c"); !found || key != "" {
+		t.Errorf("LongestPrefix(\"c\") should match the empty key, got %q, %v", key, found)
+	}
+}
+
+func TestTree_WalkPrefix(t *testing.T) {
+	tree := newTreeWithKeys("foo", "foobar", "foobaz", "fob", "bar", "f")
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"foo", []string{"foo", "foobar", "foobaz"}},
+		{"fooba", []string{"foobar", "foobaz"}},
+		{"fo", []string{"fob", "foo", "foobar", "foobaz"}},
+		{"f", []string{"f", "fob", "foo", "foobar", "foobaz"}},
+		{"", []string{"bar", "f", "fob", "foo", "foobar", "foobaz"}},
+		{"foobarx", nil},
+		{"fx", nil},
+		{"z", nil},
+	}
+	for _, tt := range tests {
+		if got := keysOf(tree.WalkPrefix(tt.prefix)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WalkPrefix(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+
+	var first []string
+	for k := range tree.WalkPrefix("foo") {
+		first = append(first, k)
+		break
+	}
+	if !reflect.DeepEqual(first, []string{"foo"}) {
+		t.Errorf("WalkPrefix should stop when yield returns false, got %v", first)
+	}
+}
+
+func TestTree_ByteSliceKeys(t *testing.T) {
+	tree := New[[]byte]()
+	tree.Insert([]byte{0x01, 0xff}, "a")
+	tree.Insert([]byte{0x01}, "b")
+	tree.Insert([]byte{0x00, 0x10}, "c")
+
+	if val, found := tree.Get([]byte{0x01, 0xff}); !found || val != "a" {
+		t.Errorf("Get([1 255]) = %v, %v", val, found)
+	}
+	key, _, found := tree.LongestPrefix([]byte{0x01, 0x02})
+	if !found || !slices.Equal(key, []byte{0x01}) {
+		t.Errorf("LongestPrefix([1 2]) = %v, %v", key, found)
+	}
+
+	var got [][]byte
+	for k := range tree.All() {
+		got = append(got, k)
+	}
+	want := [][]byte{{0x00, 0x10}, {0x01}, {0x01, 0xff}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+}
+
+func TestTree_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := New[string]()
+	model := map[string]int{}
+
+	for i := 0; i < 5000; i++ {
+		var sb strings.Builder
+		for n := rng.Intn(6); n > 0; n-- {
+			sb.WriteByte("abc"[rng.Intn(3)])
+		}
+		key := sb.String()
+
+		if rng.Intn(3) == 0 {
+			_, inModel := model[key]
+			if tree.Delete(key) != inModel {
+				t.Fatalf("Delete(%q) disagrees with the model", key)
+			}
+			delete(model, key)
+		} else {
+			tree.Insert(key, i)
+			model[key] = i
+		}
+	}
+	checkTree(t, tree)
+
+	var want []string
+	for key := range model {
+		want = append(want, key)
+	}
+	slices.Sort(want)
+	if got := keysOf(tree.All()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("All() = %v, want %v", got, want)
+	}
+	for key, val := range model {
+		if got, found := tree.Get(key); !found || got != val {
+			t.Fatalf("Get(%q) = %v, %v, want %d", key, got, found, val)
+		}
+	}
+
+	tree.Clear()
+	if !tree.IsEmpty() || tree.Len() != 0 {
+		t.Errorf("tree should be empty after Clear")
+	}
+}
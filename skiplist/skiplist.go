@@ -0,0 +1,236 @@
+package skiplist
+
+import (
+	"iter"
+	"math/rand"
+	"sync/atomic"
+)
+
+const (
+	DefaultMaxLevel    = 32
+	DefaultProbability = 0.25
+)
+
+type node struct {
+	key   int
+	value any
+	next  []atomic.Pointer[node]
+}
+
+// SkipList is an ordered map from int keys to values. Like Btree it keeps
+// duplicate keys, Get returns one of them and Remove removes one.
+//
+// Readers never lock: Get, Floor, Ceiling, Len and the iterators may run
+// concurrently with each other and with a single writer. Insert, Remove and
+// Clear must be serialized by the caller.
+type SkipList struct {
+	head        *node
+	maxLevel    int
+	probability float64
+	level       atomic.Int32 // highest level in use
+	size        atomic.Int64
+	rng         *rand.Rand
+}
+
+// New returns an empty skip list with at most maxLevel levels, where each
+// node is promoted to the next level with probability p. Levels are drawn
+// from source so tests can be deterministic, a nil source uses the global
+// math/rand generator. A maxLevel below 1 or a p outside (0, 1) falls back to
+// the defaults.
+func New(maxLevel int, p float64, source rand.Source) *SkipList {
+	if maxLevel < 1 {
+		maxLevel = DefaultMaxLevel
+	}
+	if p <= 0 || p >= 1 {
+		p = DefaultProbability
+	}
+	s := &SkipList{
+		head:        &node{next: make([]atomic.Pointer[node], maxLevel)},
+		maxLevel:    maxLevel,
+		probability: p,
+	}
+	if source != nil {
+		s.rng = rand.New(source)
+	}
+	s.level.Store(1)
+	return s
+}
+
+func (s *SkipList) randomLevel() int {
+	level := 1
+	for level < s.maxLevel && s.float64() < s.probability {
+		level++
+	}
+	return level
+}
+
+func (s *SkipList) float64() float64 {
+	if s.rng == nil {
+		return rand.Float64()
+	}
+	return s.rng.Float64()
+}
+
+// findPredecessors fills preds with the last node on every level whose key
+// is less than key and returns the node after it on the bottom level
+func (s *SkipList) findPredecessors(key int, preds []*node) *node {
+	c := s.head
+	for i := int(s.level.Load()) - 1; i >= 0; i-- {
+		for next := c.next[i].Load(); next != nil && next.key < key; next = c.next[i].Load() {
+			c = next
+		}
+		if preds != nil {
+			preds[i] = c
+		}
+	}
+	return c.next[0].Load()
+}
+
+func (s *SkipList) Insert(key int, value any) {
+	preds := make([]*node, s.maxLevel)
+	s.findPredecessors(key, preds)
+
+	level := s.randomLevel()
+	if current := int(s.level.Load()); level > current {
+		for i := current; i < level; i++ {
+			preds[i] = s.head
+		}
+		s.level.Store(int32(level))
+	}
+
+	n := &node{
+		key:   key,
+		value: value,
+		next:  make([]atomic.Pointer[node], level),
+	}
+	// link bottom up, a reader only ever sees the node once it is complete
+	// below the level it found it on
+	for i := 0; i < level; i++ {
+		n.next[i].Store(preds[i].next[i].Load())
+		preds[i].next[i].Store(n)
+	}
+	s.size.Add(1)
+}
+
+func (s *SkipList) Get(key int) (any, bool) {
+	n := s.findPredecessors(key, nil)
+	if n != nil && n.key == key {
+		return n.value, true
+	}
+	return nil, false
+}
+
+func (s *SkipList) Remove(key int) bool {
+	preds := make([]*node, s.maxLevel)
+	n := s.findPredecessors(key, preds)
+	if n == nil || n.key != key {
+		return false
+	}
+
+	// unlink top down, the node keeps its own links so readers standing on
+	// it can still move on
+	for i := len(n.next) - 1; i >= 0; i-- {
+		preds[i].next[i].Store(n.next[i].Load())
+	}
+
+	level := s.level.Load()
+	for level > 1 && s.head.next[level-1].Load() == nil {
+		level--
+	}
+	s.level.Store(level)
+	s.size.Add(-1)
+	return true
+}
+
+// Floor returns the entry with the largest key <= key
+func (s *SkipList) Floor(key int) (int, any, bool) {
+	c := s.head
+	for i := int(s.level.Load()) - 1; i >= 0; i-- {
+		for next := c.next[i].Load(); next != nil && next.key <= key; next = c.next[i].Load() {
+			c = next
+		}
+	}
+	if c == s.head {
+		return 0, nil, false
+	}
+	return c.key, c.value, true
+}
+
+// Ceiling returns the entry with the smallest key >= key
+func (s *SkipList) Ceiling(key int) (int, any, bool) {
+	n := s.findPredecessors(key, nil)
+	if n == nil {
+		return 0, nil, false
+	}
+	return n.key, n.value, true
+}
+
+// Min returns the entry with the smallest key
+func (s *SkipList) Min() (int, any, bool) {
+	n := s.head.next[0].Load()
+	if n == nil {
+		return 0, nil, false
+	}
+	return n.key, n.value, true
+}
+
+// Max returns the entry with the largest key
+func (s *SkipList) Max() (int, any, bool) {
+	c := s.head
+	for i := int(s.level.Load()) - 1; i >= 0; i-- {
+		for next := c.next[i].Load(); next != nil; next = c.next[i].Load() {
+			c = next
+		}
+	}
+	if c == s.head {
+		return 0, nil, false
+	}
+	return c.key, c.value, true
+}
+
+// All yields every entry in key order
+func (s *SkipList) All() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for n := s.head.next[0].Load(); n != nil; n = n.next[0].Load() {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range yields the entries with lo <= key < hi in key order
+func (s *SkipList) Range(lo int, hi int) iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for n := s.findPredecessors(lo, nil); n != nil && n.key < hi; n = n.next[0].Load() {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+func (s *SkipList) Len() int {
+	return int(s.size.Load())
+}
+
+func (s *SkipList) IsEmpty() bool {
+	return s.Len() == 0
+}
+
+func (s *SkipList) Clear() {
+	for i := range s.head.next {
+		s.head.next[i].Store(nil)
+	}
+	s.level.Store(1)
+	s.size.Store(0)
+}
+
+// -- Helpers for Testing and Stuff --
+func (s *SkipList) GetKeysInOrder() []int {
+	var result []int
+	for key := range s.All() {
+		result = append(result, key)
+	}
+	return result
+}
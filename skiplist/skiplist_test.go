@@ -0,0 +1,252 @@
+package skiplist
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"gihtub.com/dickeyy/go-ds/trees"
+	"gihtub.com/dickeyy/go-ds/trees/treetest"
+)
+
+func newSkipListWithKeys(seed int64, keys ...int) *SkipList {
+	s := New(DefaultMaxLevel, DefaultProbability, rand.NewSource(seed))
+	for _, key := range keys {
+		s.Insert(key, key*10)
+	}
+	return s
+}
+
+func TestSkipList_InsertAndGet(t *testing.T) {
+	s := newSkipListWithKeys(1, 10, 20, 5, 15, 25)
+
+	for _, key := range []int{5, 10, 15, 20, 25} {
+		val, found := s.Get(key)
+		if !found || val != key*10 {
+			t.Errorf("Get(%d): expected %d, true, got %v, %v", key, key*10, val, found)
+		}
+	}
+	if _, found := s.Get(12); found {
+		t.Errorf("Get(12): expected found=false for a missing key")
+	}
+	if _, found := New(4, 0.5, nil).Get(1); found {
+		t.Errorf("Get(1) on empty list: expected found=false")
+	}
+	if got := s.GetKeysInOrder(); !reflect.DeepEqual(got, []int{5, 10, 15, 20, 25}) {
+		t.Errorf("GetKeysInOrder() got %v", got)
+	}
+	if s.Len() != 5 {
+		t.Errorf("Len() got %d, want 5", s.Len())
+	}
+}
+
+func TestSkipList_Remove(t *testing.T) {
+	s := newSkipListWithKeys(2, 3, 1, 4, 1, 5, 9, 2, 6)
+
+	if s.Remove(7) {
+		t.Errorf("Remove(7): expected false for a missing key")
+	}
+	if !s.Remove(1) {
+		t.Errorf("Remove(1): expected true")
+	}
+	if got := s.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6, 9}) {
+		t.Errorf("GetKeysInOrder() after removing one duplicate got %v", got)
+	}
+	for _, key := range []int{9, 1, 3} {
+		if !s.Remove(key) {
+			t.Errorf("Remove(%d): expected true", key)
+		}
+	}
+	if got := s.GetKeysInOrder(); !reflect.DeepEqual(got, []int{2, 4, 5, 6}) {
+		t.Errorf("GetKeysInOrder() after removes got %v", got)
+	}
+	if s.Len() != 4 {
+		t.Errorf("Len() got %d, want 4", s.Len())
+	}
+
+	s.Clear()
+	if !s.IsEmpty() || len(s.GetKeysInOrder()) != 0 {
+		t.Errorf("list not empty after Clear()")
+	}
+	s.Insert(1, 1)
+	if s.Len() != 1 {
+		t.Errorf("Len() after Clear() and Insert got %d, want 1", s.Len())
+	}
+}
+
+func TestSkipList_FloorCeiling(t *testing.T) {
+	s := newSkipListWithKeys(3, 10, 20, 30)
+
+	tests := []struct {
+		key                      int
+		floor, ceiling           int
+		floorFound, ceilingFound bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{15, 10, 20, true, true},
+		{30, 30, 30, true, true},
+		{35, 30, 0, true, false},
+	}
+	for _, tc := range tests {
+		key, _, found := s.Floor(tc.key)
+		if found != tc.floorFound || (found && key != tc.floor) {
+			t.Errorf("Floor(%d) got %d, %v, want %d, %v", tc.key, key, found, tc.floor, tc.floorFound)
+		}
+		key, _, found = s.Ceiling(tc.key)
+		if found != tc.ceilingFound || (found && key != tc.ceiling) {
+			t.Errorf("Ceiling(%d) got %d, %v, want %d, %v", tc.key, key, found, tc.ceiling, tc.ceilingFound)
+		}
+	}
+
+	if key, _, _ := s.Min(); key != 10 {
+		t.Errorf("Min() got %d, want 10", key)
+	}
+	if key, _, _ := s.Max(); key != 30 {
+		t.Errorf("Max() got %d, want 30", key)
+	}
+	empty := New(8, 0.5, nil)
+	if _, _, found := empty.Min(); found {
+		t.Errorf("Min() on empty list: expected found=false")
+	}
+	if _, _, found := empty.Max(); found {
+		t.Errorf("Max() on empty list: expected found=false")
+	}
+}
+
+func TestSkipList_Range(t *testing.T) {
+	s := newSkipListWithKeys(4, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+
+	var got []int
+	for key, value := range s.Range(3, 7) {
+		if value != key*10 {
+			t.Errorf("Range yielded %d with value %v", key, value)
+		}
+		got = append(got, key)
+	}
+	if !reflect.DeepEqual(got, []int{3, 4, 5, 6}) {
+		t.Errorf("Range(3, 7) got %v", got)
+	}
+
+	got = got[:0]
+	for key := range s.Range(5, 100) {
+		if key == 7 {
+			break
+		}
+		got = append(got, key)
+	}
+	if !reflect.DeepEqual(got, []int{5, 6}) {
+		t.Errorf("Range(5, 100) stopped early got %v", got)
+	}
+}
+
+func TestSkipList_Deterministic(t *testing.T) {
+	keys := rand.New(rand.NewSource(5)).Perm(1000)
+	a := newSkipListWithKeys(42, keys...)
+	b := newSkipListWithKeys(42, keys...)
+
+	for na, nb := a.head.next[0].Load(), b.head.next[0].Load(); na != nil; na, nb = na.next[0].Load(), nb.next[0].Load() {
+		if na.key != nb.key || len(na.next) != len(nb.next) {
+			t.Fatalf("lists built from the same seed differ at key %d", na.key)
+		}
+	}
+}
+
+func TestSkipList_Options(t *testing.T) {
+	s := New(1, 0.9, rand.NewSource(6))
+	for i := 0; i < 100; i++ {
+		s.Insert(i, i)
+	}
+	for n := s.head.next[0].Load(); n != nil; n = n.next[0].Load() {
+		if len(n.next) != 1 {
+			t.Fatalf("node %d has %d levels with maxLevel 1", n.key, len(n.next))
+		}
+	}
+
+	// out of range settings fall back to the defaults
+	d := New(0, 2, nil)
+	if d.maxLevel != DefaultMaxLevel || d.probability != DefaultProbability {
+		t.Errorf("New(0, 2, nil) got maxLevel %d and p %v", d.maxLevel, d.probability)
+	}
+}
+
+func TestSkipList_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	s := New(12, 0.5, rand.NewSource(8))
+	var want []int
+	for i := 0; i < 5000; i++ {
+		key := rng.Intn(1000)
+		if rng.Intn(3) == 0 {
+			idx := slices.Index(want, key)
+			if removed := s.Remove(key); removed != (idx >= 0) {
+				t.Fatalf("Remove(%d) got %v", key, removed)
+			}
+			if idx >= 0 {
+				want = slices.Delete(want, idx, idx+1)
+			}
+		} else {
+			s.Insert(key, key)
+			want = append(want, key)
+		}
+	}
+	slices.Sort(want)
+	if got := s.GetKeysInOrder(); !slices.Equal(got, want) {
+		t.Fatalf("GetKeysInOrder() got %v, want %v", got, want)
+	}
+}
+
+// run with -race, readers must never need a lock while one writer works
+func TestSkipList_ConcurrentReaders(t *testing.T) {
+	s := New(16, 0.5, rand.NewSource(9))
+	for i := 0; i < 1000; i += 2 {
+		s.Insert(i, i)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				prev := -1
+				for key := range s.All() {
+					if key < prev {
+						t.Errorf("reader saw %d after %d", key, prev)
+						return
+					}
+					prev = key
+				}
+				// even keys are never removed
+				if _, found := s.Get(500); !found {
+					t.Errorf("reader lost key 500")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 2000; i++ {
+		key := 2*(i%500) + 1
+		if i < 1000 {
+			s.Insert(key, key)
+		} else {
+			s.Remove(key)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestSkipList_OrderedMapConformance(t *testing.T) {
+	treetest.TestOrderedMap(t, func() trees.OrderedMap[int, any] {
+		return New(DefaultMaxLevel, DefaultProbability, rand.NewSource(10))
+	})
+}
@@ -0,0 +1,216 @@
+package trees
+
+import (
+	"iter"
+)
+
+// binaryNode lets the helpers below work on the node types of every binary
+// search tree in the package. Smaller keys are on the left.
+type binaryNode[N any] interface {
+	comparable
+	key() int
+	leftChild() N
+	rightChild() N
+}
+
+func (n *BSTNode) key() int                 { return n.value }
+func (n *BSTNode) leftChild() *BSTNode      { return n.left }
+func (n *BSTNode) rightChild() *BSTNode     { return n.right }
+func (n *TreapNode) key() int               { return n.value }
+func (n *TreapNode) leftChild() *TreapNode  { return n.left }
+func (n *TreapNode) rightChild() *TreapNode { return n.right }
+func (n *SplayNode) key() int               { return n.value }
+func (n *SplayNode) leftChild() *SplayNode  { return n.left }
+func (n *SplayNode) rightChild() *SplayNode { return n.right }
+
+func binaryMin[N binaryNode[N]](node N) (int, bool) {
+	var none N
+	if node == none {
+		return 0, false
+	}
+	for node.leftChild() != none {
+		node = node.leftChild()
+	}
+	return node.key(), true
+}
+
+func binaryMax[N binaryNode[N]](node N) (int, bool) {
+	var none N
+	if node == none {
+		return 0, false
+	}
+	for node.rightChild() != none {
+		node = node.rightChild()
+	}
+	return node.key(), true
+}
+
+// binaryFloor returns the largest key <= key
+func binaryFloor[N binaryNode[N]](node N, key int) (int, bool) {
+	var none N
+	result, found := 0, false
+	for node != none {
+		if node.key() <= key {
+			result, found = node.key(), true
+			node = node.rightChild()
+		} else {
+			node = node.leftChild()
+		}
+	}
+	return result, found
+}
+
+// binaryCeiling returns the smallest key >= key
+func binaryCeiling[N binaryNode[N]](node N, key int) (int, bool) {
+	var none N
+	result, found := 0, false
+	for node != none {
+		if node.key() >= key {
+			result, found = node.key(), true
+			node = node.leftChild()
+		} else {
+			node = node.rightChild()
+		}
+	}
+	return result, found
+}
+
+// binaryAscend yields the keys >= lo in order until stop reports true for
+// one, a nil stop runs to the end. It keeps its own stack so degenerate trees
+// do not recurse deeply.
+func binaryAscend[N binaryNode[N]](root N, lo int, stop func(key int) bool) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		var none N
+		var stack []N
+		node := root
+		for node != none || len(stack) > 0 {
+			for node != none {
+				// everything on the left is smaller still
+				if node.key() < lo {
+					node = node.rightChild()
+					continue
+				}
+				stack = append(stack, node)
+				node = node.leftChild()
+			}
+			if len(stack) == 0 {
+				return
+			}
+
+			node = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if stop != nil && stop(node.key()) {
+				return
+			}
+			if !yield(node.key()) {
+				return
+			}
+			node = node.rightChild()
+		}
+	}
+}
+
+// binaryRange yields the keys with lo <= key < hi in order
+func binaryRange[N binaryNode[N]](root N, lo int, hi int) iter.Seq[int] {
+	return binaryAscend(root, lo, func(key int) bool {
+		return key >= hi
+	})
+}
+
+// binaryDescend yields every key in reverse order
+func binaryDescend[N binaryNode[N]](root N) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		var none N
+		var stack []N
+		node := root
+		for node != none || len(stack) > 0 {
+			for node != none {
+				stack = append(stack, node)
+				node = node.rightChild()
+			}
+			node = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(node.key()) {
+				return
+			}
+			node = node.leftChild()
+		}
+	}
+}
+
+// binaryPreOrder yields every key before the keys below it, the left
+// subtree before the right
+func binaryPreOrder[N binaryNode[N]](root N) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		var none N
+		var stack []N
+		if root != none {
+			stack = append(stack, root)
+		}
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(node.key()) {
+				return
+			}
+			if right := node.rightChild(); right != none {
+				stack = append(stack, right)
+			}
+			if left := node.leftChild(); left != none {
+				stack = append(stack, left)
+			}
+		}
+	}
+}
+
+// binaryPostOrder yields every key after the keys below it, the left
+// subtree before the right
+func binaryPostOrder[N binaryNode[N]](root N) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		var none N
+		var stack []N
+		var last N // the node yielded last, so a right subtree is done once
+		node := root
+		for node != none || len(stack) > 0 {
+			for node != none {
+				stack = append(stack, node)
+				node = node.leftChild()
+			}
+			top := stack[len(stack)-1]
+			if right := top.rightChild(); right != none && right != last {
+				node = right
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			if !yield(top.key()) {
+				return
+			}
+			last = top
+		}
+	}
+}
+
+// binaryLevels calls fn for every node level by level, left to right within
+// a level, until fn returns false. The root is on level 0.
+func binaryLevels[N binaryNode[N]](root N, fn func(node N, level int) bool) {
+	var none N
+	var level []N
+	if root != none {
+		level = append(level, root)
+	}
+	for depth := 0; len(level) > 0; depth++ {
+		var next []N
+		for _, node := range level {
+			if !fn(node, depth) {
+				return
+			}
+			if left := node.leftChild(); left != none {
+				next = append(next, left)
+			}
+			if right := node.rightChild(); right != none {
+				next = append(next, right)
+			}
+		}
+		level = next
+	}
+}
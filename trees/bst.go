@@ -1,6 +1,7 @@
 package trees
 
 import (
+	"iter"
 	"math"
 )
 
@@ -8,22 +9,32 @@ type BSTNode struct {
 	value int
 	left  *BSTNode
 	right *BSTNode
+	agg   *any // summary of this subtree, nil unless the tree has a monoid
 }
 
 type BST struct {
-	root *BSTNode
+	root   *BSTNode
+	size   int
+	monoid Monoid
 }
 
 func (b *BST) Insert(value int) {
 	newNode := &BSTNode{
 		value: value,
 	}
+	b.size++
+
+	// nodes whose summaries change, only tracked when a monoid is attached
+	var path []*BSTNode
 
 	if b.root == nil {
 		b.root = newNode
 	} else {
 		c := b.root
 		for c != nil {
+			if b.monoid != nil {
+				path = append(path, c)
+			}
 			if value < c.value {
 				if c.left == nil {
 					c.left = newNode
@@ -39,6 +50,9 @@ func (b *BST) Insert(value int) {
 			}
 		}
 	}
+	if b.monoid != nil {
+		b.updatePath(append(path, newNode))
+	}
 }
 
 func (b *BST) Remove(value int) bool {
@@ -48,8 +62,13 @@ func (b *BST) Remove(value int) bool {
 
 	c := b.root
 	var parent *BSTNode
+	var path []*BSTNode
 
 	for c != nil {
+		if b.monoid != nil && parent != nil {
+			path = append(path, parent)
+		}
+
 		// traverse the tree first, assume we are not at the node to remove
 		if value < c.value {
 			parent = c
@@ -69,6 +88,8 @@ func (b *BST) Remove(value int) bool {
 				} else {
 					parent.right = c.right
 				}
+				b.size--
+				b.updatePath(path)
 				return true // root has been removed
 			} else if c.right == nil {
 				// replace the node with its left child (could be nil)
@@ -79,14 +100,22 @@ func (b *BST) Remove(value int) bool {
 				} else {
 					parent.right = c.left
 				}
+				b.size--
+				b.updatePath(path)
 				return true
 			} else { // case 2: 2 children
 				// find the in-order successor (smallest node in right subtree)
 				sp := c      // successor parent
 				s := c.right // successor
+				if b.monoid != nil {
+					path = append(path, c)
+				}
 				for s.left != nil {
 					sp = s
 					s = s.left
+					if b.monoid != nil {
+						path = append(path, sp)
+					}
 				}
 				// copy values
 				c.value = s.value
@@ -97,6 +126,8 @@ func (b *BST) Remove(value int) bool {
 				} else {
 					sp.left = s.right
 				}
+				b.size--
+				b.updatePath(path)
 				return true
 			}
 		}
@@ -124,37 +155,135 @@ func (b *BST) Get(value int) *BSTNode {
 	return nil
 }
 
-// bfs
-func (b *BST) GetMinDepth() int {
-	if b.root == nil {
-		return 0
+// SetMonoid attaches m to the tree and summarizes every node with it, Insert
+// and Remove then keep the summaries along the paths they touch up to date.
+// Values are measured with a nil value. A nil m detaches it again.
+func (b *BST) SetMonoid(m Monoid) {
+	b.monoid = m
+	if m == nil {
+		return
+	}
+	// list every node before its descendants, then summarize the list
+	// backwards so children are done before their parents
+	var order []*BSTNode
+	stack := []*BSTNode{}
+	if b.root != nil {
+		stack = append(stack, b.root)
 	}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		order = append(order, node)
+		if node.left != nil {
+			stack = append(stack, node.left)
+		}
+		if node.right != nil {
+			stack = append(stack, node.right)
+		}
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		b.updateNode(order[i])
+	}
+}
 
-	queue := []*BSTNode{b.root}
-	depth := 0
+func (b *BST) updateNode(node *BSTNode) {
+	m := b.monoid
+	if node.agg == nil {
+		node.agg = new(any)
+	}
+	*node.agg = m.Combine(m.Combine(b.summary(node.left), m.Measure(node.value, nil)), b.summary(node.right))
+}
+
+func (b *BST) summary(node *BSTNode) any {
+	if node == nil {
+		return b.monoid.Identity()
+	}
+	return *node.agg
+}
 
-	for len(queue) > 0 {
-		depth++
-		levelSize := len(queue)
-		for i := 0; i < levelSize; i++ {
-			c := queue[0]
-			queue = queue[1:]
+// updatePath recomputes the summaries of path, which runs from the root down
+func (b *BST) updatePath(path []*BSTNode) {
+	if b.monoid == nil {
+		return
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		b.updateNode(path[i])
+	}
+}
 
-			if c.left == nil && c.right == nil {
-				return depth
-			}
+// Fold returns the summary of every value with lo <= value < hi under the
+// attached monoid, walking only the paths to lo and hi. Fold returns nil when
+// no monoid is attached.
+func (b *BST) Fold(lo int, hi int) any {
+	if b.monoid == nil {
+		return nil
+	}
+	m := b.monoid
 
-			if c.left != nil {
-				queue = append(queue, c.left)
-			}
+	// find the node where the paths to lo and hi part ways
+	c := b.root
+	for c != nil && (c.value < lo || c.value >= hi) {
+		if c.value < lo {
+			c = c.right
+		} else {
+			c = c.left
+		}
+	}
+	if c == nil || lo >= hi {
+		return m.Identity()
+	}
 
-			if c.right != nil {
-				queue = append(queue, c.right)
-			}
+	// values >= lo on the left, collected right to left
+	left := m.Identity()
+	for n := c.left; n != nil; {
+		if n.value >= lo {
+			left = m.Combine(m.Combine(m.Measure(n.value, nil), b.summary(n.right)), left)
+			n = n.left
+		} else {
+			n = n.right
 		}
 	}
 
-	return 0
+	// values < hi on the right, collected left to right
+	right := m.Identity()
+	for n := c.right; n != nil; {
+		if n.value < hi {
+			right = m.Combine(right, m.Combine(b.summary(n.left), m.Measure(n.value, nil)))
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+
+	return m.Combine(m.Combine(left, m.Measure(c.value, nil)), right)
+}
+
+// Len returns the number of values in the tree, counting duplicates
+func (b *BST) Len() int {
+	return b.size
+}
+
+func (b *BST) IsEmpty() bool {
+	return b.size == 0
+}
+
+func (b *BST) Clear() {
+	b.root = nil
+	b.size = 0
+}
+
+// GetMinDepth returns the number of nodes on the shortest path from the
+// root to a leaf, found level by level
+func (b *BST) GetMinDepth() int {
+	depth := 0
+	binaryLevels(b.root, func(node *BSTNode, level int) bool {
+		if node.left == nil && node.right == nil {
+			depth = level + 1
+			return false
+		}
+		return true
+	})
+	return depth
 }
 
 // dfs
@@ -193,18 +322,80 @@ func (b *BST) GetMaxDepth() int {
 	return maxDepth
 }
 
+func (b *BST) Contains(value int) bool {
+	return b.Get(value) != nil
+}
+
+func (b *BST) Min() (int, bool) {
+	return binaryMin(b.root)
+}
+
+func (b *BST) Max() (int, bool) {
+	return binaryMax(b.root)
+}
+
+// Floor returns the largest value <= value
+func (b *BST) Floor(value int) (int, bool) {
+	return binaryFloor(b.root, value)
+}
+
+// Ceiling returns the smallest value >= value
+func (b *BST) Ceiling(value int) (int, bool) {
+	return binaryCeiling(b.root, value)
+}
+
+// All yields every value in order
+func (b *BST) All() iter.Seq[int] {
+	return binaryAscend(b.root, math.MinInt, nil)
+}
+
+// Range yields the values with lo <= value < hi in order
+func (b *BST) Range(lo int, hi int) iter.Seq[int] {
+	return binaryRange(b.root, lo, hi)
+}
+
+// ReverseInOrder yields every value from largest to smallest
+func (b *BST) ReverseInOrder() iter.Seq[int] {
+	return binaryDescend(b.root)
+}
+
+// PreOrder yields every value before the values below it, left before
+// right. Inserting the values in this order into an empty BST rebuilds the
+// same tree.
+func (b *BST) PreOrder() iter.Seq[int] {
+	return binaryPreOrder(b.root)
+}
+
+// PostOrder yields every value after the values below it, left before right
+func (b *BST) PostOrder() iter.Seq[int] {
+	return binaryPostOrder(b.root)
+}
+
+// LevelOrder yields the level of every value with the value, level by level
+// and left to right within a level. The root is on level 0.
+func (b *BST) LevelOrder() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		binaryLevels(b.root, func(node *BSTNode, level int) bool {
+			return yield(level, node.value)
+		})
+	}
+}
+
 // -- Helpers for Testing and Stuff --
 func (b *BST) InOrderTraversal() []int {
-	result := []int{}
-	var traverse func(node *BSTNode)
-	traverse = func(node *BSTNode) {
-		if node == nil {
-			return
+	result := make([]int, 0, b.size)
+	var stack []*BSTNode
+	c := b.root
+	for c != nil || len(stack) > 0 {
+		// go as far left as possible, then visit and turn right
+		for c != nil {
+			stack = append(stack, c)
+			c = c.left
 		}
-		traverse(node.left)
-		result = append(result, node.value)
-		traverse(node.right)
+		c = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		result = append(result, c.value)
+		c = c.right
 	}
-	traverse(b.root)
 	return result
 }
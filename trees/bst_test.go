@@ -1,7 +1,11 @@
 package trees
 
 import (
+	"iter"
+	"math/rand"
 	"reflect"
+	"runtime/debug"
+	"slices"
 	"testing"
 )
 
@@ -372,3 +376,259 @@ func TestBST_GetMaxDepth(t *testing.T) {
 		})
 	}
 }
+
+func TestBST_LenClearIsEmpty(t *testing.T) {
+	bst := &BST{}
+	if !bst.IsEmpty() || bst.Len() != 0 {
+		t.Fatalf("new tree: expected empty with Len 0, got IsEmpty=%v Len=%d", bst.IsEmpty(), bst.Len())
+	}
+
+	values := []int{10, 5, 15, 3, 7, 12, 17, 5, 5}
+	for _, v := range values {
+		bst.Insert(v)
+	}
+	if bst.Len() != len(values) {
+		t.Errorf("Len() after inserts (with duplicates): expected %d, got %d", len(values), bst.Len())
+	}
+
+	if bst.Remove(100) {
+		t.Errorf("Remove(100): expected false for non-existent value")
+	}
+	if bst.Len() != len(values) {
+		t.Errorf("Len() after failed remove: expected %d, got %d", len(values), bst.Len())
+	}
+
+	// exercise the leaf, one child and two children cases
+	for i, v := range []int{3, 15, 10, 5} {
+		if !bst.Remove(v) {
+			t.Errorf("Remove(%d): expected true", v)
+		}
+		if bst.Len() != len(values)-i-1 {
+			t.Errorf("Len() after Remove(%d): expected %d, got %d", v, len(values)-i-1, bst.Len())
+		}
+	}
+	if bst.Len() != len(bst.InOrderTraversal()) {
+		t.Errorf("Len() = %d disagrees with InOrderTraversal() length %d", bst.Len(), len(bst.InOrderTraversal()))
+	}
+
+	bst.Clear()
+	if !bst.IsEmpty() || bst.Len() != 0 || bst.root != nil {
+		t.Errorf("after Clear(): expected empty tree, got Len=%d", bst.Len())
+	}
+}
+
+func TestBST_Fold(t *testing.T) {
+	bst := newBSTWithValues(50, 30, 70, 20, 40, 60, 80)
+	if bst.Fold(0, 100) != nil {
+		t.Errorf("Fold without a monoid should return nil")
+	}
+
+	bst.SetMonoid(NewMonoid(
+		[]int(nil),
+		func(key int, _ any) any { return []int{key} },
+		func(a any, b any) any { return append(append([]int{}, a.([]int)...), b.([]int)...) },
+	))
+
+	rng := rand.New(rand.NewSource(6))
+	want := []int{20, 30, 40, 50, 60, 70, 80}
+	for i := 0; i < 1000; i++ {
+		v := rng.Intn(100)
+		if rng.Intn(2) == 0 {
+			bst.Insert(v)
+			want = append(want, v)
+		} else if bst.Remove(v) {
+			idx := slices.Index(want, v)
+			want = slices.Delete(want, idx, idx+1)
+		}
+
+		lo := rng.Intn(110) - 5
+		hi := lo + rng.Intn(50)
+		var expected []int
+		for _, v := range slices.Sorted(slices.Values(want)) {
+			if v >= lo && v < hi {
+				expected = append(expected, v)
+			}
+		}
+		if got := bst.Fold(lo, hi).([]int); !slices.Equal(got, expected) {
+			t.Fatalf("Fold(%d, %d) = %v; want %v", lo, hi, got, expected)
+		}
+	}
+
+	count := newBSTWithValues(5, 3, 8, 1, 4, 7, 9, 3)
+	count.SetMonoid(CountMonoid())
+	if got := count.Fold(3, 8); got != 5 {
+		t.Errorf("count Fold(3, 8) = %v; want 5", got)
+	}
+	if got := count.Fold(8, 3); got != 0 {
+		t.Errorf("count Fold(8, 3) = %v; want 0", got)
+	}
+}
+
+// degenerateBST links n nodes holding 0..n-1 into a single chain, leaning
+// right or left, without going through Insert, which would take O(n^2)
+func TestBST_NoMonoidAllocs(t *testing.T) {
+	bst := newBSTWithValues(50, 30, 70, 20, 40, 60, 80)
+	next := 100
+	// only the new node itself
+	if allocs := testing.AllocsPerRun(100, func() {
+		bst.Insert(next)
+		next++
+	}); allocs != 1 {
+		t.Errorf("Insert without a monoid allocated %v times, want 1", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		next--
+		bst.Remove(next)
+	}); allocs != 0 {
+		t.Errorf("Remove without a monoid allocated %v times, want 0", allocs)
+	}
+	if bst.root.agg != nil {
+		t.Errorf("nodes of a tree without a monoid should not keep a summary")
+	}
+}
+
+func degenerateBST(n int, right bool) *BST {
+	nodes := make([]BSTNode, n)
+	for i := range nodes {
+		nodes[i].value = i
+		if right && i+1 < n {
+			nodes[i].right = &nodes[i+1]
+		}
+		if !right && i > 0 {
+			nodes[i].left = &nodes[i-1]
+		}
+	}
+	if right {
+		return &BST{root: &nodes[0], size: n}
+	}
+	return &BST{root: &nodes[n-1], size: n}
+}
+
+func TestBST_Degenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a 10M node tree")
+	}
+	// anything that recursed once per node would overflow this stack
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	const n = 10_000_000
+	bst := degenerateBST(n, true)
+	values := bst.InOrderTraversal()
+	if len(values) != n || values[0] != 0 || values[n-1] != n-1 {
+		t.Fatalf("InOrderTraversal returned %d values from %d to %d", len(values), values[0], values[len(values)-1])
+	}
+	values = nil
+
+	count := 0
+	for range bst.All() {
+		count++
+	}
+	if count != n {
+		t.Errorf("All yielded %d values, want %d", count, n)
+	}
+	if got := slices.Collect(bst.Range(n-3, n+5)); !reflect.DeepEqual(got, []int{n - 3, n - 2, n - 1}) {
+		t.Errorf("Range near the end = %v", got)
+	}
+	if !bst.Contains(n-1) || bst.Contains(n) {
+		t.Errorf("Contains is wrong at the end of the chain")
+	}
+	if depth := bst.GetMaxDepth(); depth != n {
+		t.Errorf("GetMaxDepth() = %d, want %d", depth, n)
+	}
+
+	for _, v := range []int{n - 1, 0, n / 2} {
+		if !bst.Remove(v) || bst.Contains(v) {
+			t.Errorf("Remove(%d) failed", v)
+		}
+	}
+	bst.Insert(n)
+	if max, _ := bst.Max(); max != n || bst.Len() != n-2 {
+		t.Errorf("Max() = %d with Len() %d after removes and an insert", max, bst.Len())
+	}
+
+	// a chain leaning the other way puts the whole tree on the traversal's
+	// own stack
+	bst = nil
+	left := degenerateBST(n, false)
+	if values := left.InOrderTraversal(); len(values) != n || !slices.IsSorted(values) {
+		t.Errorf("InOrderTraversal of a left chain is wrong")
+	}
+	left.SetMonoid(CountMonoid())
+	if got := left.Fold(0, n); got != n {
+		t.Errorf("Fold over a left chain = %v, want %d", got, n)
+	}
+}
+
+func TestBST_Traversals(t *testing.T) {
+	bst := newBSTWithValues(50, 30, 70, 20, 40, 60, 80)
+	tests := []struct {
+		name     string
+		seq      iter.Seq[int]
+		expected []int
+	}{
+		{"PreOrder", bst.PreOrder(), []int{50, 30, 20, 40, 70, 60, 80}},
+		{"PostOrder", bst.PostOrder(), []int{20, 40, 30, 60, 80, 70, 50}},
+		{"ReverseInOrder", bst.ReverseInOrder(), []int{80, 70, 60, 50, 40, 30, 20}},
+		{"empty PreOrder", (&BST{}).PreOrder(), nil},
+		{"empty PostOrder", (&BST{}).PostOrder(), nil},
+		{"empty ReverseInOrder", (&BST{}).ReverseInOrder(), nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := slices.Collect(tc.seq); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %v, want %v", got, tc.expected)
+			}
+			// stopping early
+			for range tc.seq {
+				break
+			}
+		})
+	}
+
+	var levels [][]int
+	for level, value := range bst.LevelOrder() {
+		if level == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], value)
+	}
+	if expected := [][]int{{50}, {30, 70}, {20, 40, 60, 80}}; !reflect.DeepEqual(levels, expected) {
+		t.Errorf("LevelOrder = %v, want %v", levels, expected)
+	}
+	for range bst.LevelOrder() {
+		break
+	}
+
+	// inserting the pre-order rebuilds the same shape, duplicates and
+	// removals included
+	rng := rand.New(rand.NewSource(7))
+	random := &BST{}
+	for i := 0; i < 2000; i++ {
+		if v := rng.Intn(500); rng.Intn(4) == 0 {
+			random.Remove(v)
+		} else {
+			random.Insert(v)
+		}
+	}
+	rebuilt := newBSTWithValues(slices.Collect(random.PreOrder())...)
+	if !reflect.DeepEqual(levelPairs(rebuilt), levelPairs(random)) {
+		t.Errorf("tree rebuilt from PreOrder has a different shape")
+	}
+	post := slices.Collect(random.PostOrder())
+	if len(post) != random.Len() || !slices.Equal(slices.Sorted(slices.Values(post)), random.InOrderTraversal()) {
+		t.Errorf("PostOrder does not yield every value once")
+	}
+	reversed := random.InOrderTraversal()
+	slices.Reverse(reversed)
+	if !slices.Equal(slices.Collect(random.ReverseInOrder()), reversed) {
+		t.Errorf("ReverseInOrder is not InOrderTraversal reversed")
+	}
+}
+
+func levelPairs(bst *BST) [][2]int {
+	var pairs [][2]int
+	for level, value := range bst.LevelOrder() {
+		pairs = append(pairs, [2]int{level, value})
+	}
+	return pairs
+}
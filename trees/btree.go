@@ -1,134 +1,214 @@
 package trees
 
 import (
+	"cmp"
 	"math"
 	"slices"
 	"sort"
 )
 
-type Btree struct {
-	root    *BtreeNode
-	order   int
-	minKeys int
-	maxKeys int
-	height  int
+// BtreeOf is a B-tree ordered by a comparison function over its keys, compare
+// returns a negative number, zero or a positive number when a sorts before,
+// with or after b. Btree is the variant with int keys and values of any
+// type.
+type BtreeOf[K any, V any] struct {
+	root     *BtreeNodeOf[K, V]
+	order    int
+	minKeys  int
+	maxKeys  int
+	height   int
+	size     int
+	monoid   MonoidOf[K]
+	compare  func(a K, b K) int
+	freelist *FreeListOf[K, V]
+	cow      *copyOnWrite // owns the nodes this tree may change in place
+	feed     *feed[K, V]
+	merkle   *merkle[K, V]
+
+	fill       float64 // how full bulk builds pack nodes, zero packs them full
+	duplicates DuplicatePolicy
 }
 
-type BtreeNode struct {
-	keys     []int
-	values   []any
-	children []*BtreeNode
+type BtreeNodeOf[K any, V any] struct {
+	keys     []K
+	values   []V
+	children []*BtreeNodeOf[K, V]
 	isLeaf   bool
+	count    int          // number of entries in this subtree
+	agg      any          // summary of this subtree under the tree's monoid
+	hash     *MerkleHash  // hash of this subtree, nil unless the tree keeps them
+	cow      *copyOnWrite // the tree that may change this node in place
 }
 
+type Btree = BtreeOf[int, any]
+
+type BtreeNode = BtreeNodeOf[int, any]
+
 func NewBtree(order int) *Btree {
+	return NewBtreeOf[int, any](order, cmp.Compare[int])
+}
+
+// NewBtreeOf creates an empty tree whose keys are ordered by compare. Keys
+// must not be modified once they are in the tree, for byte slice keys that
+// means not reusing the slice passed to Insert.
+func NewBtreeOf[K any, V any](order int, compare func(a K, b K) int) *BtreeOf[K, V] {
 	if order < 3 {
 		order = 3
 	}
-	return &Btree{
+	return &BtreeOf[K, V]{
 		order:   order,
 		minKeys: int(math.Ceil(float64(order)/2)) - 1,
 		maxKeys: order - 1,
 		height:  0,
+		compare: compare,
+		cow:     &copyOnWrite{},
 	}
 }
 
-func (b *Btree) Insert(key int, value int) {
+// Insert stores value under key. What happens when the key is already in
+// the tree depends on the tree's DuplicatePolicy, by default another entry
+// is added.
+func (b *BtreeOf[K, V]) Insert(key K, value V) {
+	if b.duplicates != AllowDuplicates {
+		b.TryInsert(key, value)
+		return
+	}
+	b.add(key, value)
+}
+
+// add inserts a new entry whether or not key is already in the tree
+func (b *BtreeOf[K, V]) add(key K, value V) {
 	if b.root == nil {
-		b.root = &BtreeNode{
-			keys:   []int{key},
-			values: []any{value},
-			isLeaf: true,
-		}
+		b.root = b.allocNode(true)
+		b.root.keys = append(b.root.keys, key)
+		b.root.values = append(b.root.values, value)
+		b.updateNode(b.root)
 		b.height++
+		b.size++
+		b.emitInsert(key, value)
 		return
 	}
 
+	b.root = b.mutable(b.root)
+
 	// if the root is full, we need to split it
-	if len(b.root.keys) == b.maxKeys {
-		newRoot := &BtreeNode{
-			keys:     []int{},
-			values:   []any{},
-			children: []*BtreeNode{b.root},
-			isLeaf:   false,
-		}
-		b.root = newRoot
-		b.splitChild(b.root, 0)
-		b.insertNonFull(b.root, key, value)
+	if b.splitsEarly() && len(b.root.keys) == b.maxKeys {
+		b.root = b.growRoot(b.root)
 		b.height++
+	}
+	b.insert(b.root, key, value)
+	if len(b.root.keys) > b.maxKeys {
+		b.root = b.growRoot(b.root)
+		b.height++
+	}
+	b.size++
+	b.emitInsert(key, value)
+}
+
+// Put stores value under key. Unlike Insert it replaces the value of an
+// entry that already has key instead of adding a duplicate.
+func (b *BtreeOf[K, V]) Put(key K, value V) {
+	old, found := b.Get(key)
+	if !found {
+		b.add(key, value)
+		return
+	}
+	b.root = b.mutable(b.root)
+	b.replace(b.root, key, value)
+	b.emit(EventUpdate, key, old, value)
+}
+
+func (b *BtreeOf[K, V]) replace(node *BtreeNodeOf[K, V], key K, value V) {
+	idx := sort.Search(len(node.keys), func(i int) bool {
+		return b.compare(node.keys[i], key) >= 0
+	})
+	if idx < len(node.keys) && b.compare(node.keys[idx], key) == 0 {
+		node.values[idx] = value
 	} else {
-		b.insertNonFull(b.root, key, value)
+		b.replace(b.mutableChild(node, idx), key, value)
 	}
+	// the summary may depend on the value
+	b.updateNode(node)
+}
+
+// splitsEarly reports whether full nodes can be split on the way down. That
+// needs an odd maxKeys so both halves keep minKeys, for odd orders nodes are
+// allowed to overflow by one and are split on the way back up instead.
+func (b *BtreeOf[K, V]) splitsEarly() bool {
+	return b.maxKeys%2 == 1
 }
 
-func (b *Btree) insertNonFull(node *BtreeNode, key int, value int) {
+func (b *BtreeOf[K, V]) insert(node *BtreeNodeOf[K, V], key K, value V) {
 	if node.isLeaf {
 		// find the insertion point using binary search
 		ip := sort.Search(len(node.keys), func(i int) bool {
-			return node.keys[i] >= key
+			return b.compare(node.keys[i], key) >= 0
 		})
 
 		// insert the key and value at ip
-		node.keys = append(node.keys, 0)
-		node.values = append(node.values, 0)
-		copy(node.keys[ip+1:], node.keys[ip:])
-		copy(node.values[ip+1:], node.values[ip:])
-		node.keys[ip] = key
-		node.values[ip] = value
+		node.keys = slices.Insert(node.keys, ip, key)
+		node.values = slices.Insert(node.values, ip, value)
 	} else {
 		// find the child to insert the key and value
 		ip_child_idx := sort.Search(len(node.keys), func(i int) bool {
-			return node.keys[i] >= key
+			return b.compare(node.keys[i], key) >= 0
 		})
 
 		// if the child is full, split it before going down
-		if len(node.children[ip_child_idx].keys) == b.maxKeys {
+		if b.splitsEarly() && len(node.children[ip_child_idx].keys) == b.maxKeys {
 			b.splitChild(node, ip_child_idx) // node is parent, ip_child_idx is index of child in parent.children
 			// after splitting, the key might go into the new right sibling
-			if key > node.keys[ip_child_idx] { // Compare with the key that was just promoted to parent
+			if b.compare(key, node.keys[ip_child_idx]) > 0 { // Compare with the key that was just promoted to parent
 				ip_child_idx++ // If key is greater, target the new right sibling
 			}
 		}
-		b.insertNonFull(node.children[ip_child_idx], key, value) // Descend into the correct child
+		b.insert(b.mutableChild(node, ip_child_idx), key, value) // Descend into the correct child
+
+		// the child overflowed, split it now that we are back up
+		if len(node.children[ip_child_idx].keys) > b.maxKeys {
+			b.splitChild(node, ip_child_idx)
+		}
 	}
+	b.updateNode(node)
 }
 
-func (b *Btree) splitChild(parent *BtreeNode, index int) {
-	child := parent.children[index]
-	newSibling := &BtreeNode{
-		isLeaf: child.isLeaf,
-	}
+func (b *BtreeOf[K, V]) splitChild(parent *BtreeNodeOf[K, V], index int) {
+	child := b.mutableChild(parent, index)
+	newSibling := b.allocNode(child.isLeaf)
 
-	medianKey := child.keys[b.minKeys]
-	medianVal := child.values[b.minKeys]
+	// for a full child this is b.minKeys, an overfull child (left behind by a
+	// join) is split as evenly as possible
+	mid := len(child.keys) / 2
+	medianKey := child.keys[mid]
+	medianVal := child.values[mid]
 
 	// move the keys and values after the median to the new sibling
-	newSibling.keys = append(newSibling.keys, child.keys[b.minKeys+1:]...)
-	newSibling.values = append(newSibling.values, child.values[b.minKeys+1:]...)
+	newSibling.keys = append(newSibling.keys, child.keys[mid+1:]...)
+	newSibling.values = append(newSibling.values, child.values[mid+1:]...)
 
 	// if this isnt a leaf, move the children after the median
 	if !child.isLeaf {
-		newSibling.children = append(newSibling.children, child.children[b.minKeys+1:]...)
-		child.children = child.children[:b.minKeys+1]
+		newSibling.children = append(newSibling.children, child.children[mid+1:]...)
+		clear(child.children[mid+1:])
+		child.children = child.children[:mid+1]
 	}
 
-	// remove the original childs keys and values and children
-	child.keys = child.keys[:b.minKeys]
-	child.values = child.values[:b.minKeys]
+	// remove the original childs keys and values and children, clearing what
+	// is cut off so the backing arrays dont keep it alive
+	clear(child.keys[mid:])
+	clear(child.values[mid:])
+	child.keys = child.keys[:mid]
+	child.values = child.values[:mid]
+	b.updateNode(child)
+	b.updateNode(newSibling)
 
-	// insert the median key and value into the parent
-	ip := sort.Search(len(parent.keys), func(i int) bool {
-		return parent.keys[i] >= medianKey
-	})
+	// the median goes right in front of the child it came from, searching for
+	// it would put it in the wrong spot when the parent holds duplicates
+	ip := index
 
 	// shift keys and values to make space
-	parent.keys = append(parent.keys, 0)
-	copy(parent.keys[ip+1:], parent.keys[ip:])
-	parent.keys[ip] = medianKey
-
-	parent.values = append(parent.values, 0)
-	copy(parent.values[ip+1:], parent.values[ip:])
-	parent.values[ip] = medianVal
+	parent.keys = slices.Insert(parent.keys, ip, medianKey)
+	parent.values = slices.Insert(parent.values, ip, medianVal)
 
 	// shift the children to make space
 	parent.children = append(parent.children, nil)
@@ -136,16 +216,46 @@ func (b *Btree) splitChild(parent *BtreeNode, index int) {
 	parent.children[ip+1] = newSibling
 }
 
-func (b *Btree) Remove(key int) bool {
+// growRoot splits an overfull root under a new root
+func (b *BtreeOf[K, V]) growRoot(root *BtreeNodeOf[K, V]) *BtreeNodeOf[K, V] {
+	newRoot := b.allocNode(false)
+	newRoot.children = append(newRoot.children, root)
+	b.splitChild(newRoot, 0)
+	b.updateNode(newRoot)
+	return newRoot
+}
+
+func (b *BtreeOf[K, V]) Remove(key K) bool {
+	value, removed := b.delete(key)
+	if removed {
+		b.emitDelete(key, value)
+	}
+	return removed
+}
+
+// delete removes an entry with key and returns its value
+func (b *BtreeOf[K, V]) delete(key K) (V, bool) {
 	if b.root == nil || len(b.root.keys) == 0 {
 		// the tree is empty or root is empty
-		return false
+		var zero V
+		return zero, false
+	}
+
+	b.root = b.mutable(b.root)
+	value, removed := b.remove(b.root, key)
+	if removed {
+		b.size--
 	}
+	b.shrinkRoot()
 
-	removed := b.remove(b.root, key)
+	return value, removed
+}
 
+// shrinkRoot drops the root if a delete left it without keys
+func (b *BtreeOf[K, V]) shrinkRoot() {
 	// if the root node becomes empty after deletion
 	if b.root != nil && len(b.root.keys) == 0 {
+		old := b.root
 		if !b.root.isLeaf {
 			// if root was an internal node and is now empty
 			// its first child becomes the new root
@@ -155,95 +265,113 @@ func (b *Btree) Remove(key int) bool {
 			b.root = nil
 			b.height = 0
 		}
+		b.freeNode(old)
 	}
+}
 
-	return removed
+// pathStep is a node on the way down and the child taken from it. Paths
+// are kept in an array on the stack while they fit, so walking down does
+// not allocate.
+type pathStep[K any, V any] struct {
+	node  *BtreeNodeOf[K, V]
+	child int
 }
 
-func (b *Btree) remove(node *BtreeNode, key int) bool {
-	// 1. find the index of the key or the child to decend into
-	idx := sort.Search(len(node.keys), func(i int) bool {
-		return node.keys[i] >= key
-	})
+func (b *BtreeOf[K, V]) remove(node *BtreeNodeOf[K, V], key K) (V, bool) {
+	var buf [32]pathStep[K, V]
+	path := buf[:0]
+	for {
+		// 1. find the index of the key or the child to decend into
+		idx := sort.Search(len(node.keys), func(i int) bool {
+			return b.compare(node.keys[i], key) >= 0
+		})
 
-	// 2. key found in current node
-	if idx < len(node.keys) && node.keys[idx] == key {
-		if node.isLeaf {
-			// case 1: key is in a leaf node
-			b.removeFromLeaf(node, idx)
-			return true
+		// 2. key found in current node
+		if idx < len(node.keys) && b.compare(node.keys[idx], key) == 0 {
+			value := node.values[idx]
+			if node.isLeaf {
+				// case 1: key is in a leaf node
+				b.removeFromLeaf(node, idx)
+			} else {
+				// case 2: key is in an internal node
+				b.removeFromInternalNode(node, idx)
+			}
+			b.rebalancePath(path)
+			return value, true
 		}
-		// case 2: key is in an internal node
-		return b.removeFromInternalNode(node, idx, key)
-	}
 
-	// 3. key not found in the current node, decend to appropriate child
-	if node.isLeaf {
-		return false // key not found
+		// 3. key not found in the current node, decend to appropriate child
+		if node.isLeaf {
+			var zero V
+			return zero, false // key not found
+		}
+		path = append(path, pathStep[K, V]{node, idx})
+		node = b.mutableChild(node, idx)
 	}
+}
 
-	// the child to descend into
-	childIdx := idx
-	child := node.children[childIdx]
-
-	// ensure the child has at least b.minKeys +1 keys before descending
-	if len(child.keys) == b.minKeys {
-		b.fillChild(node, childIdx)
-		return b.remove(node, key)
+// rebalancePath walks path back up after an entry was removed below it. A
+// child may have dropped below b.minKeys, it is topped up from a sibling or
+// merged on the way.
+func (b *BtreeOf[K, V]) rebalancePath(path []pathStep[K, V]) {
+	for i := len(path) - 1; i >= 0; i-- {
+		step := path[i]
+		if len(step.node.children[step.child].keys) < b.minKeys {
+			b.fillChild(step.node, step.child)
+		}
+		b.updateNode(step.node)
 	}
-
-	return b.remove(child, key)
 }
 
-func (b *Btree) removeFromLeaf(node *BtreeNode, keyIdx int) {
+func (b *BtreeOf[K, V]) removeFromLeaf(node *BtreeNodeOf[K, V], keyIdx int) {
 	node.keys = slices.Delete(node.keys, keyIdx, keyIdx+1)
 	node.values = slices.Delete(node.values, keyIdx, keyIdx+1)
+	b.updateNode(node)
 }
 
-func (b *Btree) removeFromInternalNode(node *BtreeNode, keyIdx int, key int) bool {
-	lChild := node.children[keyIdx]
-	rChild := node.children[keyIdx+1]
+func (b *BtreeOf[K, V]) removeFromInternalNode(node *BtreeNodeOf[K, V], keyIdx int) {
+	// replace the key with its predecessor, which always sits in a leaf
+	predKey, predVal := b.removeMax(b.mutableChild(node, keyIdx))
+	node.keys[keyIdx] = predKey
+	node.values[keyIdx] = predVal
 
-	// case 2a: left child has at least t keys (b.minKeys + 1)
-	if len(lChild.keys) > b.minKeys {
-		predKey, predVal := b.getPredecessor(lChild)
-		node.keys[keyIdx] = predKey
-		node.values[keyIdx] = predVal
-		return b.remove(lChild, predKey) // remove the predecessor from the left child
+	if len(node.children[keyIdx].keys) < b.minKeys {
+		b.fillChild(node, keyIdx)
 	}
-
-	// case 2b: right child has at least t keys
-	if len(rChild.keys) > b.minKeys {
-		succKey, succVal := b.getSuccessor(rChild)
-		node.keys[keyIdx] = succKey
-		node.values[keyIdx] = succVal
-		return b.remove(rChild, succKey) // remove the successor from the right child
-	}
-
-	// case 2c: both left and right child have exactly t-1 keys
-	// merge right and left
-	mergedNode := b.mergeChildren(node, keyIdx)
-	return b.remove(mergedNode, key)
+	b.updateNode(node)
 }
 
-func (b *Btree) getPredecessor(node *BtreeNode) (int, interface{}) {
-	c := node
-	for !c.isLeaf {
-		c = c.children[len(c.children)-1]
+// removeMax removes and returns the rightmost entry of the subtree
+func (b *BtreeOf[K, V]) removeMax(node *BtreeNodeOf[K, V]) (K, V) {
+	var buf [32]pathStep[K, V]
+	path := buf[:0]
+	for !node.isLeaf {
+		lastIdx := len(node.children) - 1
+		path = append(path, pathStep[K, V]{node, lastIdx})
+		node = b.mutableChild(node, lastIdx)
 	}
-	lastIdx := len(c.keys) - 1
-	return c.keys[lastIdx], c.values[lastIdx]
+	lastIdx := len(node.keys) - 1
+	key, value := node.keys[lastIdx], node.values[lastIdx]
+	b.removeFromLeaf(node, lastIdx)
+	b.rebalancePath(path)
+	return key, value
 }
 
-func (b *Btree) getSuccessor(node *BtreeNode) (int, interface{}) {
-	c := node
-	for !c.isLeaf {
-		c = c.children[0]
+// removeMin removes and returns the leftmost entry of the subtree
+func (b *BtreeOf[K, V]) removeMin(node *BtreeNodeOf[K, V]) (K, V) {
+	var buf [32]pathStep[K, V]
+	path := buf[:0]
+	for !node.isLeaf {
+		path = append(path, pathStep[K, V]{node, 0})
+		node = b.mutableChild(node, 0)
 	}
-	return c.keys[0], c.values[0]
+	key, value := node.keys[0], node.values[0]
+	b.removeFromLeaf(node, 0)
+	b.rebalancePath(path)
+	return key, value
 }
 
-func (b *Btree) fillChild(parent *BtreeNode, childIdx int) {
+func (b *BtreeOf[K, V]) fillChild(parent *BtreeNodeOf[K, V], childIdx int) {
 	// try borrowing from the left
 	if childIdx > 0 && len(parent.children[childIdx-1].keys) > b.minKeys {
 		b.borrowFromLeft(parent, childIdx)
@@ -261,17 +389,17 @@ func (b *Btree) fillChild(parent *BtreeNode, childIdx int) {
 	}
 }
 
-func (b *Btree) borrowFromLeft(parent *BtreeNode, childIdx int) {
-	child := parent.children[childIdx]
-	lSibling := parent.children[childIdx-1]
+func (b *BtreeOf[K, V]) borrowFromLeft(parent *BtreeNodeOf[K, V], childIdx int) {
+	child := b.mutableChild(parent, childIdx)
+	lSibling := b.mutableChild(parent, childIdx-1)
 
 	// key from parent moves down to child
 	keyFromParent := parent.keys[childIdx-1]
 	valFromParent := parent.values[childIdx-1]
 
-	// prepend key and value
-	child.keys = append([]int{keyFromParent}, child.keys...)
-	child.values = append([]any{valFromParent}, child.values...)
+	// prepend key and value, shifting in place
+	child.keys = slices.Insert(child.keys, 0, keyFromParent)
+	child.values = slices.Insert(child.values, 0, valFromParent)
 
 	// last key from left sibling moves up to the parent
 	parent.keys[childIdx-1] = lSibling.keys[len(lSibling.keys)-1]
@@ -279,14 +407,22 @@ func (b *Btree) borrowFromLeft(parent *BtreeNode, childIdx int) {
 
 	// if not a leaf, move the child pointer from left sibling to child
 	if !lSibling.isLeaf {
-		child.children = append([]*BtreeNode{lSibling.children[len(lSibling.children)-1]}, child.children...)
-		lSibling.children = lSibling.children[:len(lSibling.children)-1]
+		last := len(lSibling.children) - 1
+		child.children = slices.Insert(child.children, 0, lSibling.children[last])
+		lSibling.children = slices.Delete(lSibling.children, last, last+1)
 	}
+
+	// drop the key that moved up to the parent
+	last := len(lSibling.keys) - 1
+	lSibling.keys = slices.Delete(lSibling.keys, last, last+1)
+	lSibling.values = slices.Delete(lSibling.values, last, last+1)
+	b.updateNode(child)
+	b.updateNode(lSibling)
 }
 
-func (b *Btree) borrowFromRight(parent *BtreeNode, childIdx int) {
-	child := parent.children[childIdx]
-	rSibling := parent.children[childIdx+1]
+func (b *BtreeOf[K, V]) borrowFromRight(parent *BtreeNodeOf[K, V], childIdx int) {
+	child := b.mutableChild(parent, childIdx)
+	rSibling := b.mutableChild(parent, childIdx+1)
 
 	// key from parent moves down to child
 	keyFromParent := parent.keys[childIdx]
@@ -300,19 +436,22 @@ func (b *Btree) borrowFromRight(parent *BtreeNode, childIdx int) {
 	parent.keys[childIdx] = rSibling.keys[0]
 	parent.values[childIdx] = rSibling.values[0]
 
-	// remove the first key from the right sibling
-	rSibling.keys = rSibling.keys[1:]
-	rSibling.values = rSibling.values[1:]
+	// remove the first key from the right sibling, shifting in place so the
+	// slices keep their capacity
+	rSibling.keys = slices.Delete(rSibling.keys, 0, 1)
+	rSibling.values = slices.Delete(rSibling.values, 0, 1)
 
 	// if not a leaf, move child from right sibling to child
 	if !rSibling.isLeaf {
 		child.children = append(child.children, rSibling.children[0])
-		rSibling.children = rSibling.children[1:]
+		rSibling.children = slices.Delete(rSibling.children, 0, 1)
 	}
+	b.updateNode(child)
+	b.updateNode(rSibling)
 }
 
-func (b *Btree) mergeChildren(parent *BtreeNode, keyIdx int) *BtreeNode {
-	lChild := parent.children[keyIdx]
+func (b *BtreeOf[K, V]) mergeChildren(parent *BtreeNodeOf[K, V], keyIdx int) *BtreeNodeOf[K, V] {
+	lChild := b.mutableChild(parent, keyIdx)
 	rChild := parent.children[keyIdx+1]
 
 	// key from parent to be moved down
@@ -334,65 +473,96 @@ func (b *Btree) mergeChildren(parent *BtreeNode, keyIdx int) *BtreeNode {
 	parent.keys = slices.Delete(parent.keys, keyIdx, keyIdx+1)
 	parent.values = slices.Delete(parent.values, keyIdx, keyIdx+1)
 	parent.children = slices.Delete(parent.children, keyIdx+1, keyIdx+2)
+	b.updateNode(lChild)
+	b.freeNode(rChild)
 
 	return lChild // the new merged node
 }
 
-func (b *Btree) Get(key int) (any, bool) {
+// updateNode recomputes the cached subtree size and summary of node from its
+// keys and children, it must run after anything that changes what the node
+// holds
+func (b *BtreeOf[K, V]) updateNode(node *BtreeNodeOf[K, V]) {
+	count := len(node.keys)
+	for _, child := range node.children {
+		count += child.count
+	}
+	node.count = count
+
+	if b.monoid != nil {
+		node.agg = b.summarize(node)
+	}
+	if b.merkle != nil {
+		if node.hash == nil {
+			node.hash = new(MerkleHash)
+		}
+		*node.hash = b.merkle.hashNode(node)
+	}
+}
+
+func (b *BtreeOf[K, V]) Get(key K) (V, bool) {
 	if b.root == nil {
-		return nil, false
+		var zero V
+		return zero, false
 	}
 
 	return b.search(b.root, key)
 }
 
-func (b *Btree) search(node *BtreeNode, key int) (any, bool) {
-	idx := sort.Search(len(node.keys), func(i int) bool {
-		return node.keys[i] >= key
-	})
+func (b *BtreeOf[K, V]) search(node *BtreeNodeOf[K, V], key K) (V, bool) {
+	for {
+		idx := sort.Search(len(node.keys), func(i int) bool {
+			return b.compare(node.keys[i], key) >= 0
+		})
 
-	if idx < len(node.keys) && node.keys[idx] == key {
-		return node.values[idx], true
-	} else if node.isLeaf {
-		return nil, false
-	} else {
-		return b.search(node.children[idx], key)
+		if idx < len(node.keys) && b.compare(node.keys[idx], key) == 0 {
+			return node.values[idx], true
+		} else if node.isLeaf {
+			var zero V
+			return zero, false
+		}
+		node = node.children[idx]
 	}
 }
 
-func (b *Btree) FindMaxDepth() int {
+func (b *BtreeOf[K, V]) FindMaxDepth() int {
 	if b.root == nil {
 		return 0
 	}
 	return b.height
 }
 
-func (b *Btree) FindMinDepth() int {
+func (b *BtreeOf[K, V]) FindMinDepth() int {
 	if b.root == nil {
 		return 0
 	}
 	return b.height
 }
 
+// Len returns the number of entries in the tree, counting duplicate keys
+func (b *BtreeOf[K, V]) Len() int {
+	return b.size
+}
+
+func (b *BtreeOf[K, V]) IsEmpty() bool {
+	return b.size == 0
+}
+
+// Clear removes every entry, keeping the order the tree was created with
+func (b *BtreeOf[K, V]) Clear() {
+	events := b.entryEvents(b.root, EventDelete)
+	b.root = nil
+	b.height = 0
+	b.size = 0
+	b.feed.publish(events)
+}
+
 // -- Helpers for Testing and Stuff --
-func (b *Btree) GetKeysInOrder() []int {
-	var result []int
-	var traverse func(node *BtreeNode)
-	traverse = func(node *BtreeNode) {
-		if node == nil {
-			return
-		}
-		if node.isLeaf {
-			result = append(result, node.keys...)
-			return
-		}
-		// For internal nodes
-		for i := 0; i < len(node.keys); i++ {
-			traverse(node.children[i])
-			result = append(result, node.keys[i])
-		}
-		traverse(node.children[len(node.keys)]) // Traverse the rightmost child
-	}
-	traverse(b.root)
+func (b *BtreeOf[K, V]) GetKeysInOrder() []K {
+	var result []K
+	b.ascend(b.root, func(key K, value V) bool {
+		result = append(result, key)
+		return true
+	})
 	return result
 }
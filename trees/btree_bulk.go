@@ -0,0 +1,243 @@
+package trees
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+)
+
+// BulkLoad inserts every entry of entries. The entries are sorted and merged
+// with the tree's own, and the tree is rebuilt in one pass, which is much
+// faster than inserting them one at a time when there are many. Duplicate
+// keys are kept or replaced as the tree's policy says; if the tree rejects
+// duplicates it returns an error matching ErrDuplicateKey for a key that is
+// already there or given twice, and nothing is inserted.
+func (b *BtreeOf[K, V]) BulkLoad(entries iter.Seq2[K, V]) error {
+	return b.BulkLoadContext(context.Background(), entries)
+}
+
+// BulkLoadContext is BulkLoad stopping early when ctx is done. Nothing is
+// inserted when it returns an error.
+func (b *BtreeOf[K, V]) BulkLoadContext(ctx context.Context, entries iter.Seq2[K, V]) error {
+	check := ctxChecker{ctx: ctx}
+	var keys []K
+	var values []V
+	for key, value := range entries {
+		if err := check.err(); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if len(keys) == 0 {
+		return ctx.Err()
+	}
+
+	if !slices.IsSortedFunc(keys, b.compare) {
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortStableFunc(order, func(i int, j int) int {
+			return b.compare(keys[i], keys[j])
+		})
+		sortedKeys := make([]K, len(keys))
+		sortedValues := make([]V, len(values))
+		for i, j := range order {
+			sortedKeys[i], sortedValues[i] = keys[j], values[j]
+		}
+		keys, values = sortedKeys, sortedValues
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if b.duplicates != AllowDuplicates {
+		// a later entry replaces an earlier one, as if they were inserted in
+		// turn
+		n := 0
+		for i := range keys {
+			if i+1 < len(keys) && b.compare(keys[i], keys[i+1]) == 0 {
+				if b.duplicates == RejectDuplicates {
+					return fmt.Errorf("%w: %v", ErrDuplicateKey, keys[i])
+				}
+				continue
+			}
+			keys[n], values[n] = keys[i], values[i]
+			n++
+		}
+		keys, values = keys[:n], values[:n]
+	}
+
+	// the new entries go after existing ones with the same key, or replace
+	// them if the tree does not allow duplicates
+	active := b.feed.active()
+	var events []EventOf[K, V]
+	oldKeys, oldValues := b.appendEntries(nil, nil, b.root)
+	mergedKeys := make([]K, 0, len(oldKeys)+len(keys))
+	mergedValues := make([]V, 0, len(oldKeys)+len(keys))
+	i, j := 0, 0
+	for i < len(oldKeys) || j < len(keys) {
+		if err := check.err(); err != nil {
+			return err
+		}
+		c := -1
+		if i == len(oldKeys) {
+			c = 1
+		} else if j < len(keys) {
+			c = b.compare(oldKeys[i], keys[j])
+		}
+		switch {
+		case c < 0 || (c == 0 && b.duplicates == AllowDuplicates):
+			mergedKeys = append(mergedKeys, oldKeys[i])
+			mergedValues = append(mergedValues, oldValues[i])
+			i++
+			continue
+		case c == 0 && b.duplicates == RejectDuplicates:
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, keys[j])
+		}
+
+		event := EventOf[K, V]{Kind: EventInsert, Key: keys[j], New: values[j]}
+		if c == 0 {
+			event.Kind, event.Old = EventUpdate, oldValues[i]
+			i++
+		}
+		if active {
+			events = append(events, event)
+		}
+		mergedKeys = append(mergedKeys, keys[j])
+		mergedValues = append(mergedValues, values[j])
+		j++
+	}
+
+	b.root, b.height = b.buildSorted(mergedKeys, mergedValues)
+	b.size = len(mergedKeys)
+	b.feed.publish(events)
+	return nil
+}
+
+// buildSorted builds a subtree bottom up from entries that are already in
+// key order, packing every node as full as the fill factor and the minimum
+// fill of its neighbours allow. It runs in linear time.
+func (b *BtreeOf[K, V]) buildSorted(keys []K, values []V) (*BtreeNodeOf[K, V], int) {
+	if len(keys) == 0 {
+		return nil, 0
+	}
+
+	var children []*BtreeNodeOf[K, V]
+	height := 0
+	for {
+		height++
+		if len(keys) <= b.maxKeys {
+			return b.newNode(keys, values, children), height
+		}
+
+		// n entries spread over m nodes use m-1 of them as separators for
+		// the level above, the fewest nodes that fit is ceil((n+1)/(maxKeys+1))
+		// and the most that keep minKeys each is (n+1)/(minKeys+1)
+		nodes := (len(keys) + 1 + b.fillKeys()) / (b.fillKeys() + 1)
+		nodes = max(nodes, (len(keys)+1+b.maxKeys)/(b.maxKeys+1))
+		nodes = min(nodes, (len(keys)+1)/(b.minKeys+1))
+		perNode := (len(keys) - nodes + 1) / nodes
+		extra := (len(keys) - nodes + 1) % nodes
+
+		level := make([]*BtreeNodeOf[K, V], 0, nodes)
+		sepKeys := make([]K, 0, nodes-1)
+		sepValues := make([]V, 0, nodes-1)
+		pos, child := 0, 0
+		for i := range nodes {
+			n := perNode
+			if i < extra {
+				n++
+			}
+
+			var kids []*BtreeNodeOf[K, V]
+			if children != nil {
+				kids = children[child : child+n+1]
+				child += n + 1
+			}
+			level = append(level, b.newNode(keys[pos:pos+n], values[pos:pos+n], kids))
+			pos += n
+
+			if i < nodes-1 {
+				sepKeys = append(sepKeys, keys[pos])
+				sepValues = append(sepValues, values[pos])
+				pos++
+			}
+		}
+
+		keys, values, children = sepKeys, sepValues, level
+	}
+}
+
+// fillKeys is how many keys the nodes of a bulk build aim for
+func (b *BtreeOf[K, V]) fillKeys() int {
+	if b.fill == 0 {
+		return b.maxKeys
+	}
+	return max(b.minKeys, int(math.Round(b.fill*float64(b.maxKeys))))
+}
+
+// ascend calls fn for every entry of the subtree in key order until fn
+// returns false
+func (b *BtreeOf[K, V]) ascend(node *BtreeNodeOf[K, V], fn func(key K, value V) bool) bool {
+	var buf [16]ascendFrame[K, V]
+	return ascendStack(pushLeftmost(buf[:0], node), nil, fn)
+}
+
+// ascendFrame is a node on the path of a walk and the index of the next
+// entry to visit in it. Walks keep their path in a slice instead of
+// recursing.
+type ascendFrame[K any, V any] struct {
+	node *BtreeNodeOf[K, V]
+	next int
+}
+
+// pushLeftmost pushes node and the first child of every node below it
+func pushLeftmost[K any, V any](stack []ascendFrame[K, V], node *BtreeNodeOf[K, V]) []ascendFrame[K, V] {
+	for node != nil {
+		stack = append(stack, ascendFrame[K, V]{node: node})
+		if node.isLeaf {
+			break
+		}
+		node = node.children[0]
+	}
+	return stack
+}
+
+// ascendStack continues a walk from the path on stack, deepest node last,
+// until fn returns false or stop returns true for a key
+func ascendStack[K any, V any](stack []ascendFrame[K, V], stop func(key K) bool, fn func(key K, value V) bool) bool {
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		node, i := top.node, top.next
+		if i == len(node.keys) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		top.next++
+		if stop != nil && stop(node.keys[i]) {
+			return false
+		}
+		if !fn(node.keys[i], node.values[i]) {
+			return false
+		}
+		if !node.isLeaf {
+			stack = pushLeftmost(stack, node.children[i+1])
+		}
+	}
+	return true
+}
+
+// appendEntries appends every entry of the subtree to keys and values in key
+// order
+func (b *BtreeOf[K, V]) appendEntries(keys []K, values []V, node *BtreeNodeOf[K, V]) ([]K, []V) {
+	b.ascend(node, func(key K, value V) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	return keys, values
+}
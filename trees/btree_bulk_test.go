@@ -0,0 +1,57 @@
+package trees
+
+import (
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBtree_BulkLoad(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		rng := rand.New(rand.NewSource(int64(order)))
+		b := newBtreeWithKeys(order, 5, 1, 9)
+		var events []Event
+		b.Subscribe(func(event Event) {
+			events = append(events, event)
+		})
+
+		entries := map[int]any{}
+		for len(entries) < 500 {
+			key := rng.Intn(10000) + 10
+			entries[key] = key * 10
+		}
+		b.BulkLoad(maps.All(entries))
+
+		entries[1], entries[5], entries[9] = 10, 50, 90
+		checkBtree(t, b)
+		if got := btreeEntries(b); !reflect.DeepEqual(got, entries) {
+			t.Errorf("order %d: BulkLoad lost entries", order)
+		}
+		if b.Len() != 503 || len(events) != 500 {
+			t.Errorf("order %d: Len() = %d with %d events, want 503 and 500", order, b.Len(), len(events))
+		}
+		if !slices.IsSortedFunc(events, func(x Event, y Event) int { return x.Key - y.Key }) {
+			t.Errorf("order %d: BulkLoad events are not in key order", order)
+		}
+	}
+
+	// duplicates are kept, after the entries already there
+	b := newBtreeWithKeys(3, 1, 2)
+	b.BulkLoad(slices.All([]any{"a", "b", "c"}))
+	b.BulkLoad(slices.All([]any{"x"}))
+	checkBtree(t, b)
+	var values []any
+	for key, value := range b.All() {
+		if key == 0 {
+			values = append(values, value)
+		}
+	}
+	if !reflect.DeepEqual(values, []any{"a", "x"}) {
+		t.Errorf("values under duplicate key 0 = %v, want [a x]", values)
+	}
+	if b.Len() != 6 {
+		t.Errorf("Len() = %d, want 6", b.Len())
+	}
+}
@@ -0,0 +1,26 @@
+package trees
+
+import (
+	"context"
+)
+
+// The Context variants of long operations check their context every
+// checkEvery entries and return ctx.Err() once it is done. They make their
+// change only after the slow part is over, so a cancelled call leaves the
+// tree as it was.
+const checkEvery = 1024
+
+// ctxChecker checks a context on the first call and every checkEvery calls
+// after that
+type ctxChecker struct {
+	ctx context.Context
+	n   int
+}
+
+func (c *ctxChecker) err() error {
+	c.n++
+	if c.n%checkEvery != 1 {
+		return nil
+	}
+	return c.ctx.Err()
+}
@@ -0,0 +1,88 @@
+package trees
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBtree_ContextCancellation(t *testing.T) {
+	b := NewBtree(5)
+	for i := 0; i < 10000; i++ {
+		b.Insert(i, i)
+	}
+	before := btreeEntries(b)
+	var events []Event
+	b.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("RangeContext", func(t *testing.T) {
+		seen := 0
+		err := b.RangeContext(context.Background(), 100, 200, func(key int, value any) bool {
+			seen++
+			return true
+		})
+		if err != nil || seen != 100 {
+			t.Errorf("RangeContext saw %d entries with error %v, want 100 and nil", seen, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		seen = 0
+		err = b.RangeContext(ctx, 0, 10000, func(key int, value any) bool {
+			if seen++; seen == 2000 {
+				cancel()
+			}
+			return true
+		})
+		if !errors.Is(err, context.Canceled) || seen >= 2000+checkEvery {
+			t.Errorf("RangeContext saw %d entries with error %v after cancel", seen, err)
+		}
+	})
+
+	t.Run("DeleteRangeContext", func(t *testing.T) {
+		if n, err := b.DeleteRangeContext(cancelled, 0, 5000); n != 0 || !errors.Is(err, context.Canceled) {
+			t.Errorf("DeleteRangeContext = %d, %v, want 0 and context.Canceled", n, err)
+		}
+		checkBtree(t, b)
+		if !reflect.DeepEqual(btreeEntries(b), before) || len(events) != 0 {
+			t.Errorf("cancelled DeleteRangeContext changed the tree")
+		}
+		if n, err := b.DeleteRangeContext(context.Background(), 0, 5000); n != 5000 || err != nil {
+			t.Errorf("DeleteRangeContext = %d, %v, want 5000 and nil", n, err)
+		}
+		if len(events) != 5000 {
+			t.Errorf("DeleteRangeContext published %d events, want 5000", len(events))
+		}
+		b.BulkLoad(func(yield func(int, any) bool) {
+			for i := 0; i < 5000 && yield(i, i); i++ {
+			}
+		})
+		events = nil
+	})
+
+	t.Run("BulkLoadContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := b.BulkLoadContext(ctx, func(yield func(int, any) bool) {
+			for i := 0; i < 10*checkEvery; i++ {
+				if i == checkEvery {
+					cancel()
+				}
+				if !yield(-i, i) {
+					return
+				}
+			}
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("BulkLoadContext returned %v, want context.Canceled", err)
+		}
+		checkBtree(t, b)
+		if !reflect.DeepEqual(btreeEntries(b), before) || len(events) != 0 {
+			t.Errorf("cancelled BulkLoadContext changed the tree")
+		}
+	})
+}
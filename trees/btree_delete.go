@@ -0,0 +1,79 @@
+package trees
+
+import (
+	"context"
+)
+
+// DeleteRange removes every entry with lo <= key < hi and returns how many
+// were removed. The tree is split around the range and the two outer parts
+// are joined back together, so the span is dropped as whole subtrees and the
+// tree is only rebalanced along the two cut paths.
+func (b *BtreeOf[K, V]) DeleteRange(lo K, hi K) int {
+	removed, _ := b.DeleteRangeContext(context.Background(), lo, hi)
+	return removed
+}
+
+// DeleteRangeContext is DeleteRange stopping early when ctx is done. Only
+// telling subscribers about every removed entry takes time proportional to
+// the range, so that is done first and a cancelled call removes nothing.
+func (b *BtreeOf[K, V]) DeleteRangeContext(ctx context.Context, lo K, hi K) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if b.root == nil || b.compare(lo, hi) >= 0 {
+		return 0, nil
+	}
+
+	var events []EventOf[K, V]
+	if b.feed.active() {
+		err := b.RangeContext(ctx, lo, hi, func(key K, value V) bool {
+			events = append(events, EventOf[K, V]{Kind: EventDelete, Key: key, Old: value})
+			return true
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	left, lHeight, rest, restHeight := b.split(b.root, b.height, lo)
+	mid, _, right, rHeight := b.split(rest, restHeight, hi)
+
+	removed := 0
+	if mid != nil {
+		removed = mid.count
+	}
+
+	b.root, b.height = b.concat(left, lHeight, right, rHeight)
+	b.size -= removed
+	b.feed.publish(events)
+	return removed, nil
+}
+
+// DeleteFunc removes every entry for which pred returns true and returns how
+// many were removed. The survivors are rebuilt into a fresh tree in a single
+// pass instead of being removed one at a time.
+func (b *BtreeOf[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	var keys []K
+	var values []V
+	var events []EventOf[K, V]
+	active := b.feed.active()
+	b.ascend(b.root, func(key K, value V) bool {
+		if !pred(key, value) {
+			keys = append(keys, key)
+			values = append(values, value)
+		} else if active {
+			events = append(events, EventOf[K, V]{Kind: EventDelete, Key: key, Old: value})
+		}
+		return true
+	})
+
+	removed := b.size - len(keys)
+	if removed == 0 {
+		return 0
+	}
+
+	b.root, b.height = b.buildSorted(keys, values)
+	b.size = len(keys)
+	b.feed.publish(events)
+	return removed
+}
@@ -0,0 +1,154 @@
+package trees
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func newBtreeWithKeys(order int, keys ...int) *Btree {
+	b := NewBtree(order)
+	for _, key := range keys {
+		b.Insert(key, key*10)
+	}
+	return b
+}
+
+func TestBtree_DeleteRange(t *testing.T) {
+	tests := []struct {
+		name            string
+		order           int
+		keys            []int
+		lo, hi          int
+		expectedRemoved int
+		expectedKeys    []int
+	}{
+		{"empty tree", 3, nil, 0, 10, 0, nil},
+		{"empty range", 3, []int{1, 2, 3}, 5, 5, 0, []int{1, 2, 3}},
+		{"inverted range", 3, []int{1, 2, 3}, 3, 1, 0, []int{1, 2, 3}},
+		{"range below all keys", 3, []int{5, 6, 7}, 0, 5, 0, []int{5, 6, 7}},
+		{"range above all keys", 3, []int{5, 6, 7}, 8, 20, 0, []int{5, 6, 7}},
+		{"everything", 3, []int{5, 6, 7, 8, 9}, 0, 100, 5, nil},
+		{"hi is exclusive", 4, []int{1, 2, 3, 4, 5}, 2, 4, 2, []int{1, 4, 5}},
+		{"prefix", 5, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, 1, 5, 4, []int{5, 6, 7, 8, 9}},
+		{"suffix", 5, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, 6, 10, 4, []int{1, 2, 3, 4, 5}},
+		{"duplicates", 3, []int{4, 4, 4, 5, 3, 4, 6}, 4, 5, 4, []int{3, 5, 6}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newBtreeWithKeys(tc.order, tc.keys...)
+			removed := b.DeleteRange(tc.lo, tc.hi)
+			if removed != tc.expectedRemoved {
+				t.Errorf("DeleteRange(%d, %d) removed %d, want %d", tc.lo, tc.hi, removed, tc.expectedRemoved)
+			}
+			if keys := b.GetKeysInOrder(); !reflect.DeepEqual(keys, tc.expectedKeys) {
+				t.Errorf("GetKeysInOrder() got %v, want %v", keys, tc.expectedKeys)
+			}
+			checkBtree(t, b)
+		})
+	}
+}
+
+func TestBtree_DeleteRange_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, order := range []int{3, 4, 5, 8, 17} {
+		for round := 0; round < 50; round++ {
+			n := rng.Intn(500)
+			b := NewBtree(order)
+			var want []int
+			for i := 0; i < n; i++ {
+				key := rng.Intn(1000)
+				b.Insert(key, key*10)
+				want = append(want, key)
+			}
+			slices.Sort(want)
+
+			lo := rng.Intn(1100) - 50
+			hi := lo + rng.Intn(600)
+			expected := slices.DeleteFunc(slices.Clone(want), func(key int) bool {
+				return key >= lo && key < hi
+			})
+
+			removed := b.DeleteRange(lo, hi)
+			if removed != len(want)-len(expected) {
+				t.Fatalf("order %d: DeleteRange(%d, %d) removed %d, want %d", order, lo, hi, removed, len(want)-len(expected))
+			}
+			if keys := b.GetKeysInOrder(); !slices.Equal(keys, expected) {
+				t.Fatalf("order %d: DeleteRange(%d, %d) left %v, want %v", order, lo, hi, keys, expected)
+			}
+			checkBtree(t, b)
+
+			// the rejoined tree must keep working
+			for _, key := range expected {
+				if val, found := b.Get(key); !found || val != key*10 {
+					t.Fatalf("order %d: Get(%d) got %v, %v after DeleteRange", order, key, val, found)
+				}
+			}
+			for _, key := range expected {
+				if !b.Remove(key) {
+					t.Fatalf("order %d: Remove(%d) failed after DeleteRange", order, key)
+				}
+			}
+			checkBtree(t, b)
+		}
+	}
+}
+
+func TestBtree_DeleteFunc(t *testing.T) {
+	b := NewBtree(4)
+	for i := 0; i < 200; i++ {
+		b.Insert(i, i%7)
+	}
+
+	removed := b.DeleteFunc(func(key int, value any) bool {
+		return value.(int) == 0
+	})
+	if removed != 29 {
+		t.Errorf("DeleteFunc removed %d, want 29", removed)
+	}
+	if b.Len() != 171 {
+		t.Errorf("Len() after DeleteFunc got %d, want 171", b.Len())
+	}
+	for i := 0; i < 200; i++ {
+		_, found := b.Get(i)
+		if found != (i%7 != 0) {
+			t.Errorf("Get(%d) found=%v after DeleteFunc", i, found)
+		}
+	}
+	checkBtree(t, b)
+
+	if removed := b.DeleteFunc(func(int, any) bool { return false }); removed != 0 {
+		t.Errorf("DeleteFunc matching nothing removed %d", removed)
+	}
+
+	if removed := b.DeleteFunc(func(int, any) bool { return true }); removed != 171 {
+		t.Errorf("DeleteFunc matching everything removed %d, want 171", removed)
+	}
+	if !b.IsEmpty() {
+		t.Errorf("tree not empty after deleting everything")
+	}
+	checkBtree(t, b)
+}
+
+func TestBtree_RemoveKeepsInvariants(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, order := range []int{3, 4, 5, 6} {
+		b := NewBtree(order)
+		var keys []int
+		for i := 0; i < 400; i++ {
+			key := rng.Intn(300)
+			b.Insert(key, key)
+			keys = append(keys, key)
+		}
+		checkBtree(t, b)
+		rng.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for _, key := range keys {
+			if !b.Remove(key) {
+				t.Fatalf("order %d: Remove(%d) failed", order, key)
+			}
+			checkBtree(t, b)
+		}
+	}
+}
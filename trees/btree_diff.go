@@ -0,0 +1,185 @@
+package trees
+
+import (
+	"iter"
+	"reflect"
+)
+
+// DiffKind says how an entry differs between two trees
+type DiffKind int
+
+const (
+	DiffAdded DiffKind = iota
+	DiffRemoved
+	DiffChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// DiffEntryOf is one difference between two trees. Old is set for removed
+// and changed entries, New for added and changed ones.
+type DiffEntryOf[K any, V any] struct {
+	Kind DiffKind
+	Key  K
+	Old  V
+	New  V
+}
+
+type DiffEntry = DiffEntryOf[int, any]
+
+// Diff yields what changed from a to b in key order, comparing values with
+// reflect.DeepEqual
+func Diff(a *Btree, b *Btree) iter.Seq[DiffEntry] {
+	return DiffOf(a, b, func(x any, y any) bool {
+		return reflect.DeepEqual(x, y)
+	})
+}
+
+// DiffOf yields what changed from a to b in key order. Both trees must
+// order keys the same way. Duplicate keys are paired up in order, the extra
+// ones are added or removed.
+//
+// Subtrees that a and b share, as clones do until they are changed, are
+// skipped without being read, so diffing two snapshots of a large tree
+// costs about as much as the changes between them. Neither tree may change
+// while the diff runs.
+func DiffOf[K any, V any](a *BtreeOf[K, V], b *BtreeOf[K, V], equal func(x V, y V) bool) iter.Seq[DiffEntryOf[K, V]] {
+	return func(yield func(DiffEntryOf[K, V]) bool) {
+		left := newDiffCursor(a.root, a.height)
+		right := newDiffCursor(b.root, b.height)
+		diffCursors(a.compare, left, right, func(key K, x V, y V) bool { return equal(x, y) }, yield)
+	}
+}
+
+// diffCursors yields what changed from the entries under left to the
+// entries under right until yield returns false
+func diffCursors[K any, V any](compare func(a K, b K) int, left diffCursor[K, V], right diffCursor[K, V], equal func(key K, x V, y V) bool, yield func(DiffEntryOf[K, V]) bool) {
+	for len(left) > 0 && len(right) > 0 {
+		l, r := left.top(), right.top()
+		if l.node != nil && l.node == r.node {
+			left.pop()
+			right.pop()
+			continue
+		}
+
+		c := compare(l.first(), r.first())
+		switch {
+		case c == 0 && l.node == nil && r.node == nil:
+			left.pop()
+			right.pop()
+			if !equal(l.key, l.value, r.value) {
+				if !yield(DiffEntryOf[K, V]{Kind: DiffChanged, Key: l.key, Old: l.value, New: r.value}) {
+					return
+				}
+			}
+		case c < 0 && l.node == nil:
+			left.pop()
+			if !yield(DiffEntryOf[K, V]{Kind: DiffRemoved, Key: l.key, Old: l.value}) {
+				return
+			}
+		case c > 0 && r.node == nil:
+			right.pop()
+			if !yield(DiffEntryOf[K, V]{Kind: DiffAdded, Key: r.key, New: r.value}) {
+				return
+			}
+		case r.node == nil || (l.node != nil && l.height >= r.height):
+			// open the taller node first so that the two sides reach
+			// equal heights, where shared nodes line up
+			left.expand()
+		default:
+			right.expand()
+		}
+	}
+
+	for len(left) > 0 {
+		l := left.top()
+		if l.node != nil {
+			left.expand()
+			continue
+		}
+		left.pop()
+		if !yield(DiffEntryOf[K, V]{Kind: DiffRemoved, Key: l.key, Old: l.value}) {
+			return
+		}
+	}
+	for len(right) > 0 {
+		r := right.top()
+		if r.node != nil {
+			right.expand()
+			continue
+		}
+		right.pop()
+		if !yield(DiffEntryOf[K, V]{Kind: DiffAdded, Key: r.key, New: r.value}) {
+			return
+		}
+	}
+}
+
+// diffItem is either a whole subtree that has not been opened yet or a
+// single entry
+type diffItem[K any, V any] struct {
+	node   *BtreeNodeOf[K, V]
+	height int
+	key    K
+	value  V
+	known  bool
+}
+
+// first returns the smallest key under the item, for a subtree it is found
+// once and remembered
+func (item *diffItem[K, V]) first() K {
+	if item.node != nil && !item.known {
+		node := item.node
+		for !node.isLeaf {
+			node = node.children[0]
+		}
+		item.key = node.keys[0]
+		item.known = true
+	}
+	return item.key
+}
+
+// diffCursor is a stack of items whose top holds the smallest keys not yet
+// diffed
+type diffCursor[K any, V any] []diffItem[K, V]
+
+func newDiffCursor[K any, V any](root *BtreeNodeOf[K, V], height int) diffCursor[K, V] {
+	if root == nil || len(root.keys) == 0 {
+		return nil
+	}
+	return diffCursor[K, V]{{node: root, height: height}}
+}
+
+func (s *diffCursor[K, V]) top() *diffItem[K, V] {
+	return &(*s)[len(*s)-1]
+}
+
+func (s *diffCursor[K, V]) pop() {
+	*s = (*s)[:len(*s)-1]
+}
+
+// expand replaces the subtree on top with its children and entries
+func (s *diffCursor[K, V]) expand() {
+	item := *s.top()
+	s.pop()
+	node := item.node
+	for i := len(node.keys) - 1; i >= 0; i-- {
+		if !node.isLeaf {
+			*s = append(*s, diffItem[K, V]{node: node.children[i+1], height: item.height - 1})
+		}
+		*s = append(*s, diffItem[K, V]{key: node.keys[i], value: node.values[i]})
+	}
+	if !node.isLeaf {
+		*s = append(*s, diffItem[K, V]{node: node.children[0], height: item.height - 1})
+	}
+}
@@ -0,0 +1,138 @@
+package trees
+
+import (
+	"cmp"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func collectDiff(a *Btree, b *Btree) []DiffEntry {
+	var entries []DiffEntry
+	for entry := range Diff(a, b) {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestBtree_Diff(t *testing.T) {
+	a := newBtreeWithKeys(3, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	b := a.Clone()
+	b.Remove(2)
+	b.Put(5, "five")
+	b.Insert(10, 100)
+	b.Insert(0, 0)
+
+	expected := []DiffEntry{
+		{Kind: DiffAdded, Key: 0, New: 0},
+		{Kind: DiffRemoved, Key: 2, Old: 20},
+		{Kind: DiffChanged, Key: 5, Old: 50, New: "five"},
+		{Kind: DiffAdded, Key: 10, New: 100},
+	}
+	if got := collectDiff(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Diff(a, b) = %v, want %v", got, expected)
+	}
+
+	// trees built apart give the same diff, they just share nothing
+	c := newBtreeWithKeys(7, 0, 1, 3, 4, 5, 6, 7, 8, 9, 10)
+	c.Put(0, 0)
+	c.Put(5, "five")
+	c.Put(10, 100)
+	if got := collectDiff(a, c); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Diff(a, c) = %v, want %v", got, expected)
+	}
+
+	if got := collectDiff(a, a.Clone()); got != nil {
+		t.Errorf("Diff of a clone = %v, want none", got)
+	}
+	if got := collectDiff(NewBtree(3), a); len(got) != 9 || got[0].Kind != DiffAdded {
+		t.Errorf("Diff from an empty tree = %v, want 9 additions", got)
+	}
+	if got := collectDiff(a, NewBtree(3)); len(got) != 9 || got[8].Kind != DiffRemoved {
+		t.Errorf("Diff to an empty tree = %v, want 9 removals", got)
+	}
+
+	// stopping early
+	count := 0
+	for range Diff(a, b) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("Diff did not stop when asked")
+	}
+}
+
+func TestBtree_DiffSkipsSharedSubtrees(t *testing.T) {
+	a := NewBtreeOf[int, int](16, cmp.Compare[int])
+	for i := 0; i < 100000; i++ {
+		a.Insert(i, i)
+	}
+	b := a.Clone()
+	b.Put(500, -1)
+	b.Remove(70000)
+
+	compared := 0
+	var got []DiffEntryOf[int, int]
+	for entry := range DiffOf(a, b, func(x int, y int) bool { compared++; return x == y }) {
+		got = append(got, entry)
+	}
+	expected := []DiffEntryOf[int, int]{
+		{Kind: DiffChanged, Key: 500, Old: 500, New: -1},
+		{Kind: DiffRemoved, Key: 70000, Old: 70000},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Diff = %v, want %v", got, expected)
+	}
+	// only the nodes on the two changed paths are opened
+	if limit := 2 * a.height * 16; compared > limit {
+		t.Errorf("Diff compared %d values, want at most %d", compared, limit)
+	}
+}
+
+func TestBtree_DiffRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		a := NewBtreeOf[int, int](3+rng.Intn(6), cmp.Compare[int])
+		for n := rng.Intn(500); n > 0; n-- {
+			a.Put(rng.Intn(300), rng.Intn(5))
+		}
+		var b *BtreeOf[int, int]
+		if rng.Intn(2) == 0 {
+			b = a.Clone()
+		} else {
+			b = NewBtreeOf[int, int](3+rng.Intn(6), cmp.Compare[int])
+			for key, value := range a.All() {
+				b.Put(key, value)
+			}
+		}
+		for n := rng.Intn(50); n > 0; n-- {
+			if rng.Intn(2) == 0 {
+				b.Remove(rng.Intn(300))
+			} else {
+				b.Put(rng.Intn(300), rng.Intn(5))
+			}
+		}
+
+		var expected []DiffEntryOf[int, int]
+		for key := 0; key < 300; key++ {
+			x, inA := a.Get(key)
+			y, inB := b.Get(key)
+			switch {
+			case inA && !inB:
+				expected = append(expected, DiffEntryOf[int, int]{Kind: DiffRemoved, Key: key, Old: x})
+			case !inA && inB:
+				expected = append(expected, DiffEntryOf[int, int]{Kind: DiffAdded, Key: key, New: y})
+			case inA && inB && x != y:
+				expected = append(expected, DiffEntryOf[int, int]{Kind: DiffChanged, Key: key, Old: x, New: y})
+			}
+		}
+		var got []DiffEntryOf[int, int]
+		for entry := range DiffOf(a, b, func(x int, y int) bool { return x == y }) {
+			got = append(got, entry)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("round %d: Diff = %v, want %v", round, got, expected)
+		}
+	}
+}
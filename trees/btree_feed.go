@@ -0,0 +1,307 @@
+package trees
+
+import (
+	"errors"
+	"sync"
+)
+
+// EventKind says what a change did to an entry
+type EventKind int
+
+const (
+	EventInsert EventKind = iota
+	EventUpdate
+	EventDelete
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventInsert:
+		return "insert"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// EventOf describes one change to one entry. Seq numbers the events of a
+// tree from 1 without gaps, so a watcher that dropped events can tell. Old
+// is set for updates and deletes, New for inserts and updates.
+type EventOf[K any, V any] struct {
+	Seq  uint64
+	Kind EventKind
+	Key  K
+	Old  V
+	New  V
+}
+
+type Event = EventOf[int, any]
+
+// OverflowPolicy decides what happens when a watcher's buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the write wait until the watcher has room
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop drops the event for that watcher and counts it in Dropped
+	OverflowDrop
+	// OverflowClose stops the watcher: C is closed and Err returns
+	// ErrWatcherLagged
+	OverflowClose
+)
+
+var ErrWatcherLagged = errors.New("trees: watcher fell behind and was closed")
+
+// Changes are reported by Insert, Put, Remove, DeleteRange, DeleteFunc,
+// Clear, Split, Join and Txn.Commit, one event per entry in key order for
+// the bulk operations. Events are published after the change is made, and
+// sync callbacks run on the goroutine that made it. They must not change
+// the tree.
+
+type feed[K any, V any] struct {
+	mu       sync.Mutex
+	seq      uint64
+	handlers map[*int]func(EventOf[K, V])
+	watchers map[*WatcherOf[K, V]]struct{}
+}
+
+// WatcherOf receives the events of a tree on a buffered channel
+type WatcherOf[K any, V any] struct {
+	C <-chan EventOf[K, V]
+
+	c      chan EventOf[K, V]
+	feed   *feed[K, V]
+	policy OverflowPolicy
+	done   chan struct{}
+	stop   sync.Once
+
+	// sending is held while events go out on c, so they go out in order and
+	// c is never closed under a send. mu guards the rest and is never held
+	// while blocked on c.
+	sending sync.Mutex
+	mu      sync.Mutex
+	pending []EventOf[K, V]
+	closed  bool
+	dropped uint64
+	err     error
+}
+
+type Watcher = WatcherOf[int, any]
+
+func (b *BtreeOf[K, V]) ensureFeed() *feed[K, V] {
+	if b.feed == nil {
+		b.feed = &feed[K, V]{
+			handlers: map[*int]func(EventOf[K, V]){},
+			watchers: map[*WatcherOf[K, V]]struct{}{},
+		}
+	}
+	return b.feed
+}
+
+// Subscribe calls fn with every change to the tree until cancel is called
+func (b *BtreeOf[K, V]) Subscribe(fn func(event EventOf[K, V])) (cancel func()) {
+	f := b.ensureFeed()
+	id := new(int)
+	f.mu.Lock()
+	f.handlers[id] = fn
+	f.mu.Unlock()
+	return func() {
+		f.mu.Lock()
+		delete(f.handlers, id)
+		f.mu.Unlock()
+	}
+}
+
+// Watch delivers the changes to the tree on a channel that buffers up to
+// buffer events, policy decides what happens when it is full. The watcher
+// can be read from another goroutine than the one changing the tree.
+func (b *BtreeOf[K, V]) Watch(buffer int, policy OverflowPolicy) *WatcherOf[K, V] {
+	f := b.ensureFeed()
+	c := make(chan EventOf[K, V], max(buffer, 0))
+	w := &WatcherOf[K, V]{
+		C:      c,
+		c:      c,
+		feed:   f,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	f.mu.Lock()
+	f.watchers[w] = struct{}{}
+	f.mu.Unlock()
+	return w
+}
+
+// Cancel stops the watcher and closes C. A write blocked on the watcher is
+// released.
+func (w *WatcherOf[K, V]) Cancel() {
+	w.stop.Do(func() { close(w.done) })
+	w.sending.Lock()
+	defer w.sending.Unlock()
+	w.detach(nil)
+}
+
+// detach removes the watcher from its feed and closes C, w.sending must be
+// held
+func (w *WatcherOf[K, V]) detach(err error) {
+	w.feed.mu.Lock()
+	delete(w.feed.watchers, w)
+	w.feed.mu.Unlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	w.pending = nil
+	w.err = err
+	close(w.c)
+}
+
+// Dropped returns how many events OverflowDrop has dropped so far
+func (w *WatcherOf[K, V]) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Err returns ErrWatcherLagged once OverflowClose has closed the watcher
+func (w *WatcherOf[K, V]) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (f *feed[K, V]) active() bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.handlers) > 0 || len(f.watchers) > 0
+}
+
+// publish numbers events and hands them to every subscriber
+func (f *feed[K, V]) publish(events []EventOf[K, V]) {
+	if f == nil || len(events) == 0 {
+		return
+	}
+	f.mu.Lock()
+	for i := range events {
+		f.seq++
+		events[i].Seq = f.seq
+	}
+	handlers := make([]func(EventOf[K, V]), 0, len(f.handlers))
+	for _, fn := range f.handlers {
+		handlers = append(handlers, fn)
+	}
+	// queueing the events while they are numbered keeps every watcher in order
+	watchers := make([]*WatcherOf[K, V], 0, len(f.watchers))
+	for w := range f.watchers {
+		w.mu.Lock()
+		w.pending = append(w.pending, events...)
+		w.mu.Unlock()
+		watchers = append(watchers, w)
+	}
+	f.mu.Unlock()
+
+	// send unlocked, a blocked watcher must not hold up Subscribe, Watch or
+	// the methods of other watchers
+	for _, w := range watchers {
+		w.flush()
+	}
+
+	// callbacks run unlocked so they can subscribe or cancel
+	for _, event := range events {
+		for _, fn := range handlers {
+			fn(event)
+		}
+	}
+}
+
+// flush sends the queued events, if another write is already sending them
+// it waits for that one to finish
+func (w *WatcherOf[K, V]) flush() {
+	w.sending.Lock()
+	defer w.sending.Unlock()
+	for {
+		w.mu.Lock()
+		events := w.pending
+		w.pending = nil
+		w.mu.Unlock()
+		if len(events) == 0 || !w.send(events) {
+			return
+		}
+	}
+}
+
+// send delivers events to the watcher under its policy and reports whether
+// the watcher is still open, w.sending must be held
+func (w *WatcherOf[K, V]) send(events []EventOf[K, V]) bool {
+	for i, event := range events {
+		select {
+		case w.c <- event:
+			continue
+		default:
+		}
+
+		switch w.policy {
+		case OverflowDrop:
+			w.mu.Lock()
+			w.dropped++
+			w.mu.Unlock()
+		case OverflowClose:
+			w.detach(ErrWatcherLagged)
+			return false
+		default:
+			// a cancelled watcher has to release the writer
+			select {
+			case w.c <- event:
+			case <-w.done:
+				w.mu.Lock()
+				w.dropped += uint64(len(events) - i)
+				w.mu.Unlock()
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *BtreeOf[K, V]) emit(kind EventKind, key K, old V, new V) {
+	if b.feed.active() {
+		b.feed.publish([]EventOf[K, V]{{Kind: kind, Key: key, Old: old, New: new}})
+	}
+}
+
+func (b *BtreeOf[K, V]) emitInsert(key K, value V) {
+	var zero V
+	b.emit(EventInsert, key, zero, value)
+}
+
+func (b *BtreeOf[K, V]) emitDelete(key K, value V) {
+	var zero V
+	b.emit(EventDelete, key, value, zero)
+}
+
+// entryEvents describes every entry of the subtree as an event of kind, it
+// returns nil when nobody is listening
+func (b *BtreeOf[K, V]) entryEvents(node *BtreeNodeOf[K, V], kind EventKind) []EventOf[K, V] {
+	if !b.feed.active() {
+		return nil
+	}
+	var events []EventOf[K, V]
+	b.ascend(node, func(key K, value V) bool {
+		event := EventOf[K, V]{Kind: kind, Key: key}
+		if kind == EventDelete {
+			event.Old = value
+		} else {
+			event.New = value
+		}
+		events = append(events, event)
+		return true
+	})
+	return events
+}
@@ -0,0 +1,242 @@
+package trees
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventLog records the events of a tree without their sequence numbers
+func eventLog(t *testing.T, b *Btree) (*[]Event, func()) {
+	t.Helper()
+	var events []Event
+	var last uint64
+	cancel := b.Subscribe(func(event Event) {
+		if event.Seq != last+1 {
+			t.Errorf("event %+v out of sequence, want Seq %d", event, last+1)
+		}
+		last = event.Seq
+		event.Seq = 0
+		events = append(events, event)
+	})
+	return &events, cancel
+}
+
+func TestBtree_SubscribeEvents(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 2, 3)
+	events, cancel := eventLog(t, b)
+
+	b.Insert(4, 40)
+	b.Put(4, "four")
+	b.Put(5, 50)
+	b.Remove(1)
+	b.Remove(42)
+	b.DeleteRange(2, 4)
+	b.DeleteFunc(func(key int, value any) bool { return key == 5 })
+
+	want := []Event{
+		{Kind: EventInsert, Key: 4, New: 40},
+		{Kind: EventUpdate, Key: 4, Old: 40, New: "four"},
+		{Kind: EventInsert, Key: 5, New: 50},
+		{Kind: EventDelete, Key: 1, Old: 10},
+		{Kind: EventDelete, Key: 2, Old: 20},
+		{Kind: EventDelete, Key: 3, Old: 30},
+		{Kind: EventDelete, Key: 5, Old: 50},
+	}
+	if !reflect.DeepEqual(*events, want) {
+		t.Fatalf("events = %v, want %v", *events, want)
+	}
+
+	b.Clear()
+	if got := (*events)[len(want):]; !reflect.DeepEqual(got, []Event{{Kind: EventDelete, Key: 4, Old: "four"}}) {
+		t.Errorf("Clear events = %v", got)
+	}
+
+	cancel()
+	b.Insert(9, 90)
+	if len(*events) != len(want)+1 {
+		t.Errorf("no events should arrive after cancel")
+	}
+	if clone := b.Clone(); clone.feed != nil {
+		t.Errorf("a clone should not inherit subscribers")
+	}
+}
+
+func TestBtree_SubscribeSplitJoinTxn(t *testing.T) {
+	b := newBtreeWithKeys(4, 1, 2, 3, 4)
+	events, _ := eventLog(t, b)
+
+	left, right := b.Split(3)
+	if len(*events) != 4 {
+		t.Errorf("Split should report every entry leaving the tree, got %v", *events)
+	}
+
+	*events = nil
+	joined, _ := eventLog(t, left)
+	rightEvents, _ := eventLog(t, right)
+	left.Join(right)
+	wantJoined := []Event{{Kind: EventInsert, Key: 3, New: 30}, {Kind: EventInsert, Key: 4, New: 40}}
+	if !reflect.DeepEqual(*joined, wantJoined) {
+		t.Errorf("Join events on the receiver = %v", *joined)
+	}
+	if len(*rightEvents) != 2 || (*rightEvents)[0].Kind != EventDelete {
+		t.Errorf("Join events on the other tree = %v", *rightEvents)
+	}
+
+	*joined = nil
+	txn := left.Begin()
+	txn.Put(1, "one")
+	txn.Put(7, 70)
+	txn.Delete(2)
+	txn.Delete(8)
+	if len(*joined) != 0 {
+		t.Errorf("staged changes should not be reported before Commit")
+	}
+	txn.Commit()
+	wantTxn := []Event{
+		{Kind: EventUpdate, Key: 1, Old: 10, New: "one"},
+		{Kind: EventInsert, Key: 7, New: 70},
+		{Kind: EventDelete, Key: 2, Old: 20},
+	}
+	if !reflect.DeepEqual(*joined, wantTxn) {
+		t.Errorf("Commit events = %v, want %v", *joined, wantTxn)
+	}
+	checkBtree(t, left)
+}
+
+func TestBtree_WatchDrop(t *testing.T) {
+	b := NewBtree(3)
+	w := b.Watch(2, OverflowDrop)
+	for i := 0; i < 5; i++ {
+		b.Insert(i, i)
+	}
+	if w.Dropped() != 3 {
+		t.Errorf("Dropped() = %d, want 3", w.Dropped())
+	}
+	first, second := <-w.C, <-w.C
+	if first.Seq != 1 || second.Seq != 2 {
+		t.Errorf("kept events have Seq %d, %d", first.Seq, second.Seq)
+	}
+
+	b.Insert(5, 5)
+	if next := <-w.C; next.Seq != 6 {
+		t.Errorf("the next event should show the gap, got Seq %d", next.Seq)
+	}
+	w.Cancel()
+	if _, ok := <-w.C; ok {
+		t.Errorf("C should be closed after Cancel")
+	}
+	b.Insert(6, 6)
+}
+
+func TestBtree_WatchClose(t *testing.T) {
+	b := NewBtree(3)
+	w := b.Watch(1, OverflowClose)
+	b.Insert(1, 1)
+	b.Insert(2, 2)
+	b.Insert(3, 3)
+
+	if event := <-w.C; event.Key != 1 {
+		t.Errorf("first event = %v", event)
+	}
+	if _, ok := <-w.C; ok {
+		t.Errorf("C should be closed once the watcher fell behind")
+	}
+	if !errors.Is(w.Err(), ErrWatcherLagged) {
+		t.Errorf("Err() = %v, want ErrWatcherLagged", w.Err())
+	}
+	w.Cancel()
+	if !errors.Is(w.Err(), ErrWatcherLagged) {
+		t.Errorf("Cancel should keep the error")
+	}
+}
+
+func TestBtree_WatchBlock(t *testing.T) {
+	b := NewBtree(4)
+	w := b.Watch(4, OverflowBlock)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var got []int
+	go func() {
+		defer wg.Done()
+		for event := range w.C {
+			got = append(got, event.Key)
+			if len(got) == 1000 {
+				w.Cancel()
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		b.Insert(i, nil)
+	}
+	wg.Wait()
+
+	for i, key := range got {
+		if key != i {
+			t.Fatalf("event %d is for key %d, a blocking watcher must not lose events", i, key)
+		}
+	}
+	if len(got) != 1000 {
+		t.Errorf("received %d events, want 1000", len(got))
+	}
+}
+
+func TestBtree_WatchCancelReleasesWriter(t *testing.T) {
+	b := NewBtree(3)
+	w := b.Watch(0, OverflowBlock)
+
+	done := make(chan struct{})
+	go func() {
+		b.Insert(1, 1)
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	w.Cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Cancel should release a write blocked on the watcher")
+	}
+	if b.Len() != 1 {
+		t.Errorf("the blocked write should still be applied")
+	}
+}
+
+func TestBtree_WatchBlockDoesNotStallFeed(t *testing.T) {
+	b := NewBtree(3)
+	blocked := b.Watch(0, OverflowBlock)
+	other := b.Watch(0, OverflowDrop)
+
+	written := make(chan struct{})
+	go func() {
+		b.Insert(1, 1)
+		close(written)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		other.Dropped()
+		other.Err()
+		b.Subscribe(func(Event) {})()
+		b.Watch(1, OverflowDrop).Cancel()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("a blocked watcher should not stall the other watchers")
+	}
+
+	if event := <-blocked.C; event.Key != 1 {
+		t.Errorf("blocked watcher got key %d, want 1", event.Key)
+	}
+	<-written
+	if other.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", other.Dropped())
+	}
+}
@@ -0,0 +1,131 @@
+package trees
+
+import (
+	"sort"
+)
+
+// SetMonoid attaches m to the tree and summarizes every node with it. The
+// summaries are then kept up to date by every operation that changes a node,
+// including splits, merges, borrows and joins. A nil m detaches it again.
+func (b *BtreeOf[K, V]) SetMonoid(m MonoidOf[K]) {
+	b.monoid = m
+	b.root = b.resummarize(b.root)
+}
+
+// resummarize recomputes the cached data of every node in the subtree,
+// copying the nodes it does not own
+func (b *BtreeOf[K, V]) resummarize(node *BtreeNodeOf[K, V]) *BtreeNodeOf[K, V] {
+	if node == nil {
+		return nil
+	}
+	node = b.mutable(node)
+	for i, child := range node.children {
+		node.children[i] = b.resummarize(child)
+	}
+	b.updateNode(node)
+	return node
+}
+
+// summarize folds the entries and children of node, in key order, into one
+// summary
+func (b *BtreeOf[K, V]) summarize(node *BtreeNodeOf[K, V]) any {
+	m := b.monoid
+	agg := m.Identity()
+	for i := range node.keys {
+		if !node.isLeaf {
+			agg = m.Combine(agg, node.children[i].agg)
+		}
+		agg = m.Combine(agg, m.Measure(node.keys[i], node.values[i]))
+	}
+	if !node.isLeaf {
+		agg = m.Combine(agg, node.children[len(node.keys)].agg)
+	}
+	return agg
+}
+
+// Fold returns the summary of every entry with lo <= key < hi under the
+// attached monoid. It combines the cached summaries of the subtrees that lie
+// fully inside the range, so it only walks the two paths to lo and hi. Fold
+// returns nil when no monoid is attached.
+func (b *BtreeOf[K, V]) Fold(lo K, hi K) any {
+	if b.monoid == nil {
+		return nil
+	}
+	if b.root == nil || b.compare(lo, hi) >= 0 {
+		return b.monoid.Identity()
+	}
+	return b.fold(b.root, lo, hi)
+}
+
+func (b *BtreeOf[K, V]) fold(node *BtreeNodeOf[K, V], lo K, hi K) any {
+	m := b.monoid
+	from := sort.Search(len(node.keys), func(i int) bool {
+		return b.compare(node.keys[i], lo) >= 0
+	})
+	to := sort.Search(len(node.keys), func(i int) bool {
+		return b.compare(node.keys[i], hi) >= 0
+	})
+
+	// no entry of this node is in range, so the range sits inside one child
+	if from == to {
+		if node.isLeaf {
+			return m.Identity()
+		}
+		return b.fold(node.children[from], lo, hi)
+	}
+
+	agg := m.Identity()
+	if !node.isLeaf {
+		agg = b.foldFrom(node.children[from], lo)
+	}
+	for i := from; i < to; i++ {
+		agg = m.Combine(agg, m.Measure(node.keys[i], node.values[i]))
+		if !node.isLeaf && i+1 < to {
+			agg = m.Combine(agg, node.children[i+1].agg)
+		}
+	}
+	if !node.isLeaf {
+		agg = m.Combine(agg, b.foldUntil(node.children[to], hi))
+	}
+	return agg
+}
+
+// foldFrom summarizes the entries of the subtree with keys >= lo
+func (b *BtreeOf[K, V]) foldFrom(node *BtreeNodeOf[K, V], lo K) any {
+	m := b.monoid
+	from := sort.Search(len(node.keys), func(i int) bool {
+		return b.compare(node.keys[i], lo) >= 0
+	})
+
+	agg := m.Identity()
+	if !node.isLeaf {
+		agg = b.foldFrom(node.children[from], lo)
+	}
+	for i := from; i < len(node.keys); i++ {
+		agg = m.Combine(agg, m.Measure(node.keys[i], node.values[i]))
+		if !node.isLeaf {
+			agg = m.Combine(agg, node.children[i+1].agg)
+		}
+	}
+	return agg
+}
+
+// foldUntil summarizes the entries of the subtree with keys < hi
+func (b *BtreeOf[K, V]) foldUntil(node *BtreeNodeOf[K, V], hi K) any {
+	m := b.monoid
+	to := sort.Search(len(node.keys), func(i int) bool {
+		return b.compare(node.keys[i], hi) >= 0
+	})
+
+	agg := m.Identity()
+	for i := 0; i < to; i++ {
+		if !node.isLeaf {
+			agg = m.Combine(agg, node.children[i].agg)
+		}
+		agg = m.Combine(agg, m.Measure(node.keys[i], node.values[i]))
+	}
+	if !node.isLeaf {
+		agg = m.Combine(agg, b.foldUntil(node.children[to], hi))
+	}
+	return agg
+}
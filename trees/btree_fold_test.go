@@ -0,0 +1,147 @@
+package trees
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func sumMonoid() Monoid {
+	return NewMonoid(
+		0,
+		func(key int, value any) any { return value.(int) },
+		func(a any, b any) any { return a.(int) + b.(int) },
+	)
+}
+
+// keysMonoid lists the keys it summarizes, it is not commutative so it also
+// catches summaries combined out of order
+func keysMonoid() Monoid {
+	return NewMonoid(
+		[]int(nil),
+		func(key int, value any) any { return []int{key} },
+		func(a any, b any) any { return append(slices.Clone(a.([]int)), b.([]int)...) },
+	)
+}
+
+func TestBtree_Fold(t *testing.T) {
+	b := NewBtree(4)
+	if b.Fold(0, 10) != nil {
+		t.Errorf("Fold without a monoid should return nil")
+	}
+
+	b.SetMonoid(sumMonoid())
+	if got := b.Fold(0, 10); got != 0 {
+		t.Errorf("Fold on empty tree got %v, want 0", got)
+	}
+
+	for i := 1; i <= 100; i++ {
+		b.Insert(i, i)
+	}
+
+	tests := []struct {
+		lo, hi   int
+		expected int
+	}{
+		{1, 101, 5050},
+		{-100, 1000, 5050},
+		{1, 11, 55},
+		{50, 51, 50},
+		{50, 50, 0},
+		{60, 50, 0},
+		{91, 200, 955},
+		{200, 300, 0},
+	}
+	for _, tc := range tests {
+		if got := b.Fold(tc.lo, tc.hi); got != tc.expected {
+			t.Errorf("Fold(%d, %d) got %v, want %d", tc.lo, tc.hi, got, tc.expected)
+		}
+	}
+}
+
+// checkBtreeSummaries verifies the cached summary of every node against a
+// fresh computation
+func checkBtreeSummaries(t *testing.T, b *Btree) {
+	t.Helper()
+	var check func(node *BtreeNode)
+	check = func(node *BtreeNode) {
+		for _, child := range node.children {
+			check(child)
+		}
+		if want := b.summarize(node); !slices.Equal(node.agg.([]int), want.([]int)) {
+			t.Fatalf("node %v has summary %v, want %v", node.keys, node.agg, want)
+		}
+	}
+	if b.root != nil {
+		check(b.root)
+	}
+}
+
+func TestBtree_Fold_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, order := range []int{3, 4, 7} {
+		b := NewBtree(order)
+		b.SetMonoid(keysMonoid())
+		var keys []int
+		for i := 0; i < 2000; i++ {
+			key := rng.Intn(400)
+			if rng.Intn(3) == 0 {
+				if b.Remove(key) {
+					keys = slices.Delete(keys, slices.Index(keys, key), slices.Index(keys, key)+1)
+				}
+			} else {
+				b.Insert(key, key)
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		checkBtreeSummaries(t, b)
+
+		for i := 0; i < 200; i++ {
+			lo := rng.Intn(450) - 25
+			hi := lo + rng.Intn(200)
+			var want []int
+			for _, key := range keys {
+				if key >= lo && key < hi {
+					want = append(want, key)
+				}
+			}
+			if got := b.Fold(lo, hi).([]int); !slices.Equal(got, want) {
+				t.Fatalf("order %d: Fold(%d, %d) got %v, want %v", order, lo, hi, got, want)
+			}
+		}
+	}
+}
+
+func TestBtree_Fold_AcrossStructuralOperations(t *testing.T) {
+	b := NewBtree(5)
+	b.SetMonoid(keysMonoid())
+	for i := 0; i < 500; i++ {
+		b.Insert(i, i)
+	}
+
+	b.DeleteRange(100, 200)
+	checkBtreeSummaries(t, b)
+
+	left, right := b.Split(321)
+	checkBtreeSummaries(t, left)
+	checkBtreeSummaries(t, right)
+
+	// nodes coming from a tree without the monoid are summarized on join
+	plain := NewBtree(5)
+	for i := 1000; i < 1100; i++ {
+		plain.Insert(i, i)
+	}
+	right.Join(plain)
+	left.Join(right)
+	checkBtreeSummaries(t, left)
+
+	left.DeleteFunc(func(key int, value any) bool { return key%3 == 0 })
+	checkBtreeSummaries(t, left)
+
+	union := left.Union(newBtreeWithKeys(5, 150, 160), nil)
+	checkBtreeSummaries(t, union)
+	if got := union.Fold(140, 170).([]int); !slices.Equal(got, []int{150, 160}) {
+		t.Errorf("Fold over a union got %v", got)
+	}
+}
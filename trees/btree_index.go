@@ -0,0 +1,153 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+)
+
+// IndexedBtreeOf is a collection kept in a primary tree with any number of
+// secondary indexes over derived keys. Every write to the collection updates
+// the indexes along with the primary tree, so they never disagree. Keys of
+// the primary tree are unique, index keys may repeat.
+type IndexedBtreeOf[K any, V any] struct {
+	primary *BtreeOf[K, V]
+	indexes []secondaryIndex[K, V]
+}
+
+type IndexedBtree = IndexedBtreeOf[int, any]
+
+// secondaryIndex is what the collection needs from an index whatever its
+// key type
+type secondaryIndex[K any, V any] interface {
+	add(key K, value V)
+	remove(key K, value V)
+}
+
+func NewIndexedBtree(order int) *IndexedBtree {
+	return NewIndexedBtreeOf[int, any](order, cmp.Compare[int])
+}
+
+func NewIndexedBtreeOf[K any, V any](order int, compare func(a K, b K) int) *IndexedBtreeOf[K, V] {
+	return &IndexedBtreeOf[K, V]{primary: NewBtreeOf[K, V](order, compare)}
+}
+
+// Put stores value under key, replacing the value it had, and moves the
+// entry to its new place in every index
+func (c *IndexedBtreeOf[K, V]) Put(key K, value V) {
+	if old, found := c.primary.Get(key); found {
+		for _, index := range c.indexes {
+			index.remove(key, old)
+		}
+	}
+	c.primary.Put(key, value)
+	for _, index := range c.indexes {
+		index.add(key, value)
+	}
+}
+
+func (c *IndexedBtreeOf[K, V]) Get(key K) (V, bool) {
+	return c.primary.Get(key)
+}
+
+// Delete removes key from the primary tree and every index
+func (c *IndexedBtreeOf[K, V]) Delete(key K) bool {
+	old, found := c.primary.delete(key)
+	if !found {
+		return false
+	}
+	for _, index := range c.indexes {
+		index.remove(key, old)
+	}
+	return true
+}
+
+// All yields every entry in primary key order
+func (c *IndexedBtreeOf[K, V]) All() iter.Seq2[K, V] {
+	return c.primary.All()
+}
+
+// Range yields the entries with lo <= key < hi in primary key order
+func (c *IndexedBtreeOf[K, V]) Range(lo K, hi K) iter.Seq2[K, V] {
+	return c.primary.Range(lo, hi)
+}
+
+func (c *IndexedBtreeOf[K, V]) Len() int {
+	return c.primary.Len()
+}
+
+// IndexOf orders the entries of a collection by a key derived from their
+// values. Entries with equal index keys are ordered by their primary key.
+type IndexOf[K any, V any, IK any] struct {
+	collection *IndexedBtreeOf[K, V]
+	extract    func(value V) IK
+	entries    *BtreeSetOf[indexEntry[IK, K]]
+}
+
+// indexEntry is an index key paired with the primary key it belongs to. A
+// bound entry has no primary key and sorts before (-1) or after (+1) every
+// entry with its index key, which is how scans find their ends.
+type indexEntry[IK any, K any] struct {
+	key     IK
+	primary K
+	bound   int
+}
+
+// NewIndex adds an index to c that orders its entries by extract(value)
+// under compare, and fills it from the entries already in c. It is a
+// function rather than a method because the index key type is its own.
+func NewIndex[K any, V any, IK any](c *IndexedBtreeOf[K, V], extract func(value V) IK, compare func(a IK, b IK) int) *IndexOf[K, V, IK] {
+	primaryCompare := c.primary.compare
+	index := &IndexOf[K, V, IK]{
+		collection: c,
+		extract:    extract,
+		entries: NewBtreeSetOf(c.primary.order, func(a indexEntry[IK, K], b indexEntry[IK, K]) int {
+			if n := compare(a.key, b.key); n != 0 {
+				return n
+			}
+			if a.bound != 0 || b.bound != 0 {
+				return cmp.Compare(a.bound, b.bound)
+			}
+			return primaryCompare(a.primary, b.primary)
+		}),
+	}
+	for key, value := range c.primary.All() {
+		index.add(key, value)
+	}
+	c.indexes = append(c.indexes, index)
+	return index
+}
+
+func (x *IndexOf[K, V, IK]) add(key K, value V) {
+	x.entries.Add(indexEntry[IK, K]{key: x.extract(value), primary: key})
+}
+
+func (x *IndexOf[K, V, IK]) remove(key K, value V) {
+	x.entries.Delete(indexEntry[IK, K]{key: x.extract(value), primary: key})
+}
+
+// Get yields the entries whose index key is key, in primary key order
+func (x *IndexOf[K, V, IK]) Get(key IK) iter.Seq2[K, V] {
+	return x.scan(indexEntry[IK, K]{key: key, bound: -1}, indexEntry[IK, K]{key: key, bound: 1})
+}
+
+// Range yields the entries with lo <= index key < hi in index key order
+func (x *IndexOf[K, V, IK]) Range(lo IK, hi IK) iter.Seq2[K, V] {
+	return x.scan(indexEntry[IK, K]{key: lo, bound: -1}, indexEntry[IK, K]{key: hi, bound: -1})
+}
+
+func (x *IndexOf[K, V, IK]) scan(lo indexEntry[IK, K], hi indexEntry[IK, K]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for entry := range x.entries.Range(lo, hi) {
+			value, _ := x.collection.primary.Get(entry.primary)
+			if !yield(entry.primary, value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of entries in the index, which is the size of the
+// collection
+func (x *IndexOf[K, V, IK]) Len() int {
+	return x.entries.Len()
+}
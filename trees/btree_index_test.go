@@ -0,0 +1,121 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	name string
+	city string
+	age  int
+}
+
+func collectIndexed[K any, V any](seq iter.Seq2[K, V]) []K {
+	var keys []K
+	for key := range seq {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestIndexedBtree_Lookups(t *testing.T) {
+	c := NewIndexedBtreeOf[int, person](3, cmp.Compare[int])
+	c.Put(1, person{"ada", "london", 36})
+	c.Put(2, person{"alan", "london", 41})
+
+	// an index added late is filled from the entries already there
+	byCity := NewIndex(c, func(p person) string { return p.city }, strings.Compare)
+	c.Put(3, person{"grace", "new york", 85})
+	c.Put(4, person{"edsger", "austin", 72})
+	byAge := NewIndex(c, func(p person) int { return p.age }, cmp.Compare[int])
+
+	if got := collectIndexed(byCity.Get("london")); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("byCity.Get(london) = %v, want [1 2]", got)
+	}
+	if got := collectIndexed(byCity.Get("paris")); got != nil {
+		t.Errorf("byCity.Get(paris) = %v, want none", got)
+	}
+	if got := collectIndexed(byAge.Range(40, 80)); !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Errorf("byAge.Range(40, 80) = %v, want [2 4]", got)
+	}
+	for key, p := range byCity.Get("austin") {
+		if key != 4 || p.name != "edsger" {
+			t.Errorf("byCity.Get(austin) yielded %d %v", key, p)
+		}
+	}
+
+	// an update moves the entry in every index
+	c.Put(2, person{"alan", "manchester", 41})
+	if got := collectIndexed(byCity.Get("london")); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("after update byCity.Get(london) = %v, want [1]", got)
+	}
+	if got := collectIndexed(byCity.Get("manchester")); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("after update byCity.Get(manchester) = %v, want [2]", got)
+	}
+
+	if !c.Delete(1) || c.Delete(1) {
+		t.Errorf("Delete(1) should remove the entry once")
+	}
+	if got := collectIndexed(byCity.Range("a", "z")); !reflect.DeepEqual(got, []int{4, 2, 3}) {
+		t.Errorf("byCity.Range(a, z) = %v, want [4 2 3]", got)
+	}
+	if c.Len() != 3 || byCity.Len() != 3 || byAge.Len() != 3 {
+		t.Errorf("Len() = %d, %d, %d, want 3", c.Len(), byCity.Len(), byAge.Len())
+	}
+}
+
+func TestIndexedBtree_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	c := NewIndexedBtreeOf[int, int](4, cmp.Compare[int])
+	mod := NewIndex(c, func(value int) int { return value % 10 }, cmp.Compare[int])
+	model := map[int]int{}
+
+	for i := 0; i < 3000; i++ {
+		key := rng.Intn(200)
+		if rng.Intn(3) == 0 {
+			if got := c.Delete(key); got != hasKey(model, key) {
+				t.Fatalf("Delete(%d) = %v", key, got)
+			}
+			delete(model, key)
+		} else {
+			value := rng.Intn(1000)
+			c.Put(key, value)
+			model[key] = value
+		}
+	}
+
+	if mod.Len() != len(model) {
+		t.Fatalf("index Len() = %d, want %d", mod.Len(), len(model))
+	}
+	for bucket := 0; bucket < 10; bucket++ {
+		var expected []int
+		for key := 0; key < 200; key++ {
+			if value, ok := model[key]; ok && value%10 == bucket {
+				expected = append(expected, key)
+			}
+		}
+		t.Run(fmt.Sprintf("bucket=%d", bucket), func(t *testing.T) {
+			var got []int
+			for key, value := range mod.Get(bucket) {
+				if value != model[key] {
+					t.Errorf("key %d yielded value %d, want %d", key, value, model[key])
+				}
+				got = append(got, key)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Get(%d) = %v, want %v", bucket, got, expected)
+			}
+		})
+	}
+}
+
+func hasKey(m map[int]int, key int) bool {
+	_, ok := m[key]
+	return ok
+}
@@ -0,0 +1,110 @@
+package trees
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrCorruptEntries is returned by ReadEntries for a stream that was not
+// written by WriteEntries
+var ErrCorruptEntries = errors.New("trees: corrupt entry stream")
+
+// maxEntrySize bounds the size of one encoded entry read back, so a corrupt
+// length cannot make ReadEntries allocate without limit
+const maxEntrySize = 1 << 30
+
+// WriteEntries writes every entry to w in key order, encode turns an entry
+// into bytes. Each entry is written as its length as a uvarint followed by
+// its bytes.
+func (b *BtreeOf[K, V]) WriteEntries(w io.Writer, encode func(key K, value V) ([]byte, error)) error {
+	return b.WriteEntriesContext(context.Background(), w, encode)
+}
+
+// WriteEntriesContext is WriteEntries stopping early when ctx is done. What
+// was written so far is not a complete copy of the tree.
+func (b *BtreeOf[K, V]) WriteEntriesContext(ctx context.Context, w io.Writer, encode func(key K, value V) ([]byte, error)) error {
+	check := ctxChecker{ctx: ctx}
+	bw := bufio.NewWriter(w)
+	var err error
+	b.ascend(b.root, func(key K, value V) bool {
+		if err = check.err(); err != nil {
+			return false
+		}
+		var data []byte
+		if data, err = encode(key, value); err != nil {
+			return false
+		}
+		if _, err = bw.Write(binary.AppendUvarint(nil, uint64(len(data)))); err != nil {
+			return false
+		}
+		_, err = bw.Write(data)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadEntries reads a stream written by WriteEntries until EOF and bulk
+// loads its entries into b, decode turns the bytes of an entry back into
+// the entry. b is only changed if the whole stream is read.
+func (b *BtreeOf[K, V]) ReadEntries(r io.Reader, decode func(data []byte) (K, V, error)) error {
+	return b.ReadEntriesContext(context.Background(), r, decode)
+}
+
+// ReadEntriesContext is ReadEntries stopping early when ctx is done
+func (b *BtreeOf[K, V]) ReadEntriesContext(ctx context.Context, r io.Reader, decode func(data []byte) (K, V, error)) error {
+	check := ctxChecker{ctx: ctx}
+	br := bufio.NewReader(r)
+	var keys []K
+	var values []V
+	for {
+		if err := check.err(); err != nil {
+			return err
+		}
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return corruptEntries(err)
+		}
+		if size > maxEntrySize {
+			return ErrCorruptEntries
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return corruptEntries(err)
+		}
+		key, value, err := decode(data)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	return b.BulkLoadContext(ctx, func(yield func(K, V) bool) {
+		for i := range keys {
+			if !yield(keys[i], values[i]) {
+				return
+			}
+		}
+	})
+}
+
+// corruptEntries reports a stream that ends in the middle of an entry as
+// corrupt and passes other read errors through
+func corruptEntries(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorruptEntries
+	}
+	return err
+}
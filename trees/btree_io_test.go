@@ -0,0 +1,78 @@
+package trees
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBtree_WriteReadEntries(t *testing.T) {
+	encode := func(key int, value any) ([]byte, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value %v is not a string", value)
+		}
+		return fmt.Appendf(nil, "%d:%s", key, s), nil
+	}
+	decode := func(data []byte) (int, any, error) {
+		k, v, ok := strings.Cut(string(data), ":")
+		if !ok {
+			return 0, nil, fmt.Errorf("no separator in %q", data)
+		}
+		key, err := strconv.Atoi(k)
+		return key, v, err
+	}
+
+	b := NewBtree(4)
+	for i := 0; i < 3000; i++ {
+		b.Insert(i*7%3000, strconv.Itoa(i))
+	}
+	var buf bytes.Buffer
+	if err := b.WriteEntries(&buf, encode); err != nil {
+		t.Fatalf("WriteEntries: %v", err)
+	}
+	data := buf.Bytes()
+
+	c := NewBtree(6)
+	if err := c.ReadEntries(bytes.NewReader(data), decode); err != nil {
+		t.Fatalf("ReadEntries: %v", err)
+	}
+	checkBtree(t, c)
+	if !reflect.DeepEqual(btreeEntries(c), btreeEntries(b)) {
+		t.Errorf("ReadEntries did not restore the tree")
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		d := newBtreeWithKeys(3, 1)
+		if err := d.ReadEntries(bytes.NewReader(data[:len(data)-2]), decode); !errors.Is(err, ErrCorruptEntries) {
+			t.Errorf("truncated stream returned %v, want ErrCorruptEntries", err)
+		}
+		if err := d.ReadEntries(bytes.NewReader([]byte{3, 'x', 'y', 'z'}), decode); err == nil {
+			t.Errorf("decode error was not returned")
+		}
+		if d.Len() != 1 {
+			t.Errorf("failed ReadEntries changed the tree")
+		}
+		if err := newBtreeWithKeys(3, 1).WriteEntries(&bytes.Buffer{}, encode); err == nil {
+			t.Errorf("encode error was not returned")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var out bytes.Buffer
+		if err := b.WriteEntriesContext(ctx, &out, encode); !errors.Is(err, context.Canceled) {
+			t.Errorf("WriteEntriesContext returned %v, want context.Canceled", err)
+		}
+		d := NewBtree(3)
+		if err := d.ReadEntriesContext(ctx, bytes.NewReader(data), decode); !errors.Is(err, context.Canceled) || d.Len() != 0 {
+			t.Errorf("ReadEntriesContext returned %v with %d entries, want context.Canceled and none", err, d.Len())
+		}
+	})
+}
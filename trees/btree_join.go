@@ -0,0 +1,319 @@
+package trees
+
+import (
+	"slices"
+	"sort"
+)
+
+// Split and join work on bare subtrees described by their root and height,
+// a nil root has height 0. Both consume their inputs: the nodes they are
+// given end up in the result, so callers must not keep using them.
+
+// subtree wraps root in a Btree sharing b's configuration. It also shares
+// b's copy-on-write token, so it may change b's nodes in place; a tree that
+// is handed out must be given a token of its own.
+func (b *BtreeOf[K, V]) subtree(root *BtreeNodeOf[K, V], height int) *BtreeOf[K, V] {
+	t := &BtreeOf[K, V]{
+		root:     root,
+		order:    b.order,
+		minKeys:  b.minKeys,
+		maxKeys:  b.maxKeys,
+		height:   height,
+		monoid:   b.monoid,
+		compare:  b.compare,
+		freelist: b.freelist,
+		cow:      b.cow,
+		merkle:   b.merkle,
+
+		fill:       b.fill,
+		duplicates: b.duplicates,
+	}
+	if root != nil {
+		t.size = root.count
+	}
+	return t
+}
+
+// split divides the subtree rooted at node into the entries with keys less
+// than key and the entries with keys greater than or equal to key
+func (b *BtreeOf[K, V]) split(node *BtreeNodeOf[K, V], height int, key K) (*BtreeNodeOf[K, V], int, *BtreeNodeOf[K, V], int) {
+	if node == nil {
+		return nil, 0, nil, 0
+	}
+
+	idx := sort.Search(len(node.keys), func(i int) bool {
+		return b.compare(node.keys[i], key) >= 0
+	})
+
+	if node.isLeaf {
+		var left, right *BtreeNodeOf[K, V]
+		var lHeight, rHeight int
+		if idx > 0 {
+			left = b.newNode(node.keys[:idx], node.values[:idx], nil)
+			lHeight = 1
+		}
+		if idx < len(node.keys) {
+			right = b.newNode(node.keys[idx:], node.values[idx:], nil)
+			rHeight = 1
+		}
+		return left, lHeight, right, rHeight
+	}
+
+	// everything that straddles key lives in children[idx]
+	cLeft, cLeftHeight, cRight, cRightHeight := b.split(node.children[idx], height-1, key)
+
+	left, lHeight := cLeft, cLeftHeight
+	if idx > 0 {
+		// keys[:idx-1] with children[:idx] is a valid subtree, keys[idx-1]
+		// separates it from what was split off children[idx]
+		piece, pieceHeight := b.piece(node, 0, idx-1, height)
+		left, lHeight = b.join(piece, pieceHeight, node.keys[idx-1], node.values[idx-1], cLeft, cLeftHeight)
+	}
+
+	right, rHeight := cRight, cRightHeight
+	if idx < len(node.keys) {
+		piece, pieceHeight := b.piece(node, idx+1, len(node.keys), height)
+		right, rHeight = b.join(cRight, cRightHeight, node.keys[idx], node.values[idx], piece, pieceHeight)
+	}
+
+	return left, lHeight, right, rHeight
+}
+
+// piece copies keys[from:to] of an internal node together with the children
+// around them, a piece without keys collapses into its only child
+func (b *BtreeOf[K, V]) piece(node *BtreeNodeOf[K, V], from int, to int, height int) (*BtreeNodeOf[K, V], int) {
+	if from == to {
+		return node.children[from], height - 1
+	}
+	return b.newNode(node.keys[from:to], node.values[from:to], node.children[from:to+1]), height
+}
+
+// newNode builds a node from copies of the given slices
+func (b *BtreeOf[K, V]) newNode(keys []K, values []V, children []*BtreeNodeOf[K, V]) *BtreeNodeOf[K, V] {
+	node := b.allocNode(len(children) == 0)
+	node.keys = append(node.keys, keys...)
+	node.values = append(node.values, values...)
+	node.children = append(node.children, children...)
+	b.updateNode(node)
+	return node
+}
+
+// join concatenates left, the entry key/value and right. Every key in left
+// must be <= key and every key in right must be >= key. It only walks down
+// the spine of the taller tree to the height of the shorter one.
+func (b *BtreeOf[K, V]) join(
+	left *BtreeNodeOf[K, V],
+	lHeight int,
+	key K,
+	value V,
+	right *BtreeNodeOf[K, V],
+	rHeight int,
+) (*BtreeNodeOf[K, V], int) {
+	switch {
+	case left == nil && right == nil:
+		return b.newNode([]K{key}, []V{value}, nil), 1
+	case left == nil:
+		t := b.subtree(right, rHeight)
+		t.add(key, value)
+		return t.root, t.height
+	case right == nil:
+		t := b.subtree(left, lHeight)
+		t.add(key, value)
+		return t.root, t.height
+	}
+
+	var root *BtreeNodeOf[K, V]
+	switch {
+	case lHeight > rHeight:
+		root = b.joinRight(left, lHeight, key, value, right, rHeight)
+	case lHeight < rHeight:
+		root = b.joinLeft(left, lHeight, key, value, right, rHeight)
+	default:
+		// same height, hang both under a new root when they are big enough
+		// to be ordinary children
+		if len(left.keys) >= b.minKeys && len(right.keys) >= b.minKeys {
+			root = b.newNode([]K{key}, []V{value}, []*BtreeNodeOf[K, V]{left, right})
+			return root, lHeight + 1
+		}
+		root = b.newNode(
+			append(append(slices.Clone(left.keys), key), right.keys...),
+			append(append(slices.Clone(left.values), value), right.values...),
+			append(slices.Clone(left.children), right.children...),
+		)
+	}
+
+	height := max(lHeight, rHeight)
+	if len(root.keys) > b.maxKeys {
+		root = b.growRoot(root)
+		height++
+	}
+	return root, height
+}
+
+// joinRight hangs right off the right spine of node, the returned node may
+// hold one key too many and has to be split by the caller
+func (b *BtreeOf[K, V]) joinRight(node *BtreeNodeOf[K, V], height int, key K, value V, right *BtreeNodeOf[K, V], rHeight int) *BtreeNodeOf[K, V] {
+	node = b.mutable(node)
+	if height == rHeight+1 {
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, value)
+		node.children = append(node.children, right)
+		if len(right.keys) < b.minKeys {
+			b.rebalance(node, len(node.keys)-1)
+		}
+	} else {
+		last := len(node.children) - 1
+		child := b.joinRight(node.children[last], height-1, key, value, right, rHeight)
+		node.children[last] = child
+		if len(child.keys) > b.maxKeys {
+			b.splitChild(node, last)
+		}
+	}
+	b.updateNode(node)
+	return node
+}
+
+// joinLeft is the mirror image of joinRight
+func (b *BtreeOf[K, V]) joinLeft(left *BtreeNodeOf[K, V], lHeight int, key K, value V, node *BtreeNodeOf[K, V], height int) *BtreeNodeOf[K, V] {
+	node = b.mutable(node)
+	if height == lHeight+1 {
+		node.keys = slices.Insert(node.keys, 0, key)
+		node.values = slices.Insert(node.values, 0, value)
+		node.children = slices.Insert(node.children, 0, left)
+		if len(left.keys) < b.minKeys {
+			b.rebalance(node, 0)
+		}
+	} else {
+		child := b.joinLeft(left, lHeight, key, value, node.children[0], height-1)
+		node.children[0] = child
+		if len(child.keys) > b.maxKeys {
+			b.splitChild(node, 0)
+		}
+	}
+	b.updateNode(node)
+	return node
+}
+
+// rebalance evens out children keyIdx and keyIdx+1 of parent by merging them
+// and splitting the result again if it is too big for one node
+func (b *BtreeOf[K, V]) rebalance(parent *BtreeNodeOf[K, V], keyIdx int) {
+	merged := b.mergeChildren(parent, keyIdx)
+	if len(merged.keys) > b.maxKeys {
+		b.splitChild(parent, keyIdx)
+	}
+}
+
+// concat joins two subtrees without a separating entry by borrowing the
+// smallest entry of right
+func (b *BtreeOf[K, V]) concat(left *BtreeNodeOf[K, V], lHeight int, right *BtreeNodeOf[K, V], rHeight int) (*BtreeNodeOf[K, V], int) {
+	if left == nil {
+		return right, rHeight
+	}
+	if right == nil {
+		return left, lHeight
+	}
+	t := b.subtree(right, rHeight)
+	key, value := t.popMin()
+	return b.join(left, lHeight, key, value, t.root, t.height)
+}
+
+// popMin removes and returns the leftmost entry, the tree must not be empty
+func (b *BtreeOf[K, V]) popMin() (K, V) {
+	b.root = b.mutable(b.root)
+	key, value := b.removeMin(b.root)
+	b.size--
+	b.shrinkRoot()
+	return key, value
+}
+
+// Split cuts the tree at key. left holds every entry with a key less than
+// key and right every other entry. Only the nodes along the path to key are
+// rebuilt, b itself is left empty.
+func (b *BtreeOf[K, V]) Split(key K) (left *BtreeOf[K, V], right *BtreeOf[K, V]) {
+	// the split may change the old nodes, so describe them first
+	events := b.entryEvents(b.root, EventDelete)
+	l, lHeight, r, rHeight := b.split(b.root, b.height, key)
+	b.root, b.height, b.size = nil, 0, 0
+	left, right = b.subtree(l, lHeight), b.subtree(r, rHeight)
+	// the nodes may end up in clones of either half, none of the three
+	// trees can keep changing them in place
+	b.cow, left.cow, right.cow = &copyOnWrite{}, &copyOnWrite{}, &copyOnWrite{}
+	b.feed.publish(events)
+	return left, right
+}
+
+// Join moves every entry of other into b. The key ranges of the two trees
+// must not overlap, other may sit either entirely above or entirely below b.
+// Trees of the same order are joined along one spine in O(log n), other
+// orders fall back to rebuilding from both trees in key order. Join reports
+// false and leaves both trees untouched when the ranges overlap. Unless b
+// allows duplicates, a key at the end of one tree and the start of the other
+// counts as overlap too, and so do repeated keys in other.
+func (b *BtreeOf[K, V]) Join(other *BtreeOf[K, V]) bool {
+	if other == b || other.root == nil {
+		return other.root == nil
+	}
+
+	lo, hi := b, other
+	if b.root != nil {
+		if b.compare(firstKey(other.root), firstKey(b.root)) < 0 {
+			lo, hi = other, b
+		}
+		c := b.compare(lastKey(lo.root), firstKey(hi.root))
+		if c > 0 || (c == 0 && b.duplicates != AllowDuplicates) {
+			return false
+		}
+	}
+	if b.duplicates != AllowDuplicates && other.duplicates == AllowDuplicates && b.hasDuplicates(other.root) {
+		return false
+	}
+
+	deletes := other.entryEvents(other.root, EventDelete)
+	inserts := b.entryEvents(other.root, EventInsert)
+
+	if b.order == other.order && (!sameMonoid(b.monoid, other.monoid) || b.merkle != other.merkle) {
+		// the nodes of other are summarized or hashed differently
+		other.root = b.resummarize(other.root)
+	}
+
+	if b.order != other.order {
+		keys, values := b.appendEntries(nil, nil, lo.root)
+		keys, values = b.appendEntries(keys, values, hi.root)
+		b.root, b.height = b.buildSorted(keys, values)
+	} else {
+		b.root, b.height = b.concat(lo.root, lo.height, hi.root, hi.height)
+	}
+	b.size = lo.size + hi.size
+	// other must not change the nodes it handed over in place
+	other.root, other.height, other.size = nil, 0, 0
+	other.cow = &copyOnWrite{}
+	other.feed.publish(deletes)
+	b.feed.publish(inserts)
+	return true
+}
+
+// hasDuplicates reports whether a key repeats under node
+func (b *BtreeOf[K, V]) hasDuplicates(node *BtreeNodeOf[K, V]) bool {
+	var prev K
+	first, found := true, false
+	b.ascend(node, func(key K, value V) bool {
+		found = !first && b.compare(prev, key) == 0
+		prev, first = key, false
+		return !found
+	})
+	return found
+}
+
+func firstKey[K any, V any](node *BtreeNodeOf[K, V]) K {
+	for !node.isLeaf {
+		node = node.children[0]
+	}
+	return node.keys[0]
+}
+
+func lastKey[K any, V any](node *BtreeNodeOf[K, V]) K {
+	for !node.isLeaf {
+		node = node.children[len(node.children)-1]
+	}
+	return node.keys[len(node.keys)-1]
+}
@@ -0,0 +1,225 @@
+package trees
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBtree_Split(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, order := range []int{3, 4, 5, 9} {
+		for round := 0; round < 50; round++ {
+			var keys []int
+			b := NewBtree(order)
+			for i := rng.Intn(300); i > 0; i-- {
+				key := rng.Intn(500)
+				b.Insert(key, key*10)
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+
+			at := rng.Intn(600) - 50
+			cut, _ := slices.BinarySearch(keys, at)
+
+			left, right := b.Split(at)
+			if !b.IsEmpty() {
+				t.Fatalf("order %d: tree not empty after Split", order)
+			}
+			if got := left.GetKeysInOrder(); !slices.Equal(got, keys[:cut]) {
+				t.Fatalf("order %d: Split(%d) left got %v, want %v", order, at, got, keys[:cut])
+			}
+			if got := right.GetKeysInOrder(); !slices.Equal(got, keys[cut:]) {
+				t.Fatalf("order %d: Split(%d) right got %v, want %v", order, at, got, keys[cut:])
+			}
+			if left.Len() != cut || right.Len() != len(keys)-cut {
+				t.Fatalf("order %d: Split(%d) sizes %d and %d, want %d and %d",
+					order, at, left.Len(), right.Len(), cut, len(keys)-cut)
+			}
+			checkBtree(t, left)
+			checkBtree(t, right)
+
+			// both halves must keep working as ordinary trees
+			right.Insert(at+1000, 0)
+			left.Insert(at-1000, 0)
+			checkBtree(t, left)
+			checkBtree(t, right)
+		}
+	}
+}
+
+func TestBtree_Join(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, orders := range [][2]int{{3, 3}, {4, 4}, {5, 5}, {3, 7}, {8, 4}} {
+		for round := 0; round < 50; round++ {
+			lo := NewBtree(orders[0])
+			hi := NewBtree(orders[1])
+			var want []int
+			for i := rng.Intn(200); i > 0; i-- {
+				key := rng.Intn(1000)
+				lo.Insert(key, key)
+				want = append(want, key)
+			}
+			for i := rng.Intn(200); i > 0; i-- {
+				key := 1000 + rng.Intn(1000)
+				hi.Insert(key, key)
+				want = append(want, key)
+			}
+			slices.Sort(want)
+
+			// alternate which side the receiver is on
+			b, other := lo, hi
+			if round%2 == 1 {
+				b, other = hi, lo
+			}
+			if !b.Join(other) {
+				t.Fatalf("orders %v: Join of disjoint trees failed", orders)
+			}
+			if !other.IsEmpty() {
+				t.Fatalf("orders %v: other not empty after Join", orders)
+			}
+			if got := b.GetKeysInOrder(); !slices.Equal(got, want) {
+				t.Fatalf("orders %v: Join got %v, want %v", orders, got, want)
+			}
+			if b.Len() != len(want) {
+				t.Fatalf("orders %v: Len() after Join got %d, want %d", orders, b.Len(), len(want))
+			}
+			checkBtree(t, b)
+		}
+	}
+}
+
+func TestBtree_Join_Overlapping(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 5, 9)
+	other := newBtreeWithKeys(3, 4, 12)
+
+	if b.Join(other) {
+		t.Fatalf("Join of overlapping trees succeeded")
+	}
+	if got := b.GetKeysInOrder(); !slices.Equal(got, []int{1, 5, 9}) {
+		t.Errorf("receiver changed by failed Join: %v", got)
+	}
+	if got := other.GetKeysInOrder(); !slices.Equal(got, []int{4, 12}) {
+		t.Errorf("other changed by failed Join: %v", got)
+	}
+
+	// touching ranges are fine, keys may repeat
+	touching := newBtreeWithKeys(3, 9, 10)
+	if !b.Join(touching) {
+		t.Fatalf("Join of touching trees failed")
+	}
+	if got := b.GetKeysInOrder(); !slices.Equal(got, []int{1, 5, 9, 9, 10}) {
+		t.Errorf("Join of touching trees got %v", got)
+	}
+}
+
+func TestBtree_Join_Duplicates(t *testing.T) {
+	withKeys := func(policy DuplicatePolicy, keys ...int) *Btree {
+		b, _ := NewBtreeWithOptions(BtreeOptions{Order: 3, Duplicates: policy})
+		for _, key := range keys {
+			b.Insert(key, key)
+		}
+		return b
+	}
+
+	for _, policy := range []DuplicatePolicy{ReplaceDuplicates, RejectDuplicates} {
+		b, other := withKeys(policy, 1, 5), withKeys(policy, 5, 9)
+		if b.Join(other) {
+			t.Errorf("%v: Join with a shared boundary key succeeded", policy)
+		}
+		if got := b.GetKeysInOrder(); !slices.Equal(got, []int{1, 5}) {
+			t.Errorf("%v: receiver changed by failed Join: %v", policy, got)
+		}
+		if got := other.GetKeysInOrder(); !slices.Equal(got, []int{5, 9}) {
+			t.Errorf("%v: other changed by failed Join: %v", policy, got)
+		}
+
+		// other may hold repeats of its own when it allows them
+		if b.Join(withKeys(AllowDuplicates, 7, 7)) {
+			t.Errorf("%v: Join of a tree with repeated keys succeeded", policy)
+		}
+		if !b.Join(withKeys(AllowDuplicates, 6, 7)) {
+			t.Errorf("%v: Join of disjoint trees failed", policy)
+		}
+		if got := b.GetKeysInOrder(); !slices.Equal(got, []int{1, 5, 6, 7}) {
+			t.Errorf("%v: Join got %v", policy, got)
+		}
+	}
+}
+
+func TestBtree_SplitThenJoin(t *testing.T) {
+	b := NewBtree(4)
+	for i := 0; i < 1000; i++ {
+		b.Insert(i, i)
+	}
+	left, right := b.Split(437)
+	if !left.Join(right) {
+		t.Fatalf("Join of split halves failed")
+	}
+	checkBtree(t, left)
+	for i := 0; i < 1000; i++ {
+		if val, found := left.Get(i); !found || val != i {
+			t.Fatalf("Get(%d) got %v, %v after Split and Join", i, val, found)
+		}
+	}
+}
+
+func TestBtree_SplitJoinCloneIsolation(t *testing.T) {
+	for _, free := range []*FreeList{nil, NewFreeList(16)} {
+		newTree := func(lo, hi int) *Btree {
+			b := NewBtree(3)
+			if free != nil {
+				b.SetFreeList(free)
+			}
+			for i := lo; i < hi; i++ {
+				b.Insert(i, i)
+			}
+			return b
+		}
+		check := func(name string, snap *Btree, want []int) {
+			t.Helper()
+			checkBtree(t, snap)
+			if got := snap.GetKeysInOrder(); !slices.Equal(got, want) {
+				t.Errorf("freelist %v: %s: snapshot changed to %v", free != nil, name, got)
+			}
+		}
+		keys := func(lo, hi int) []int {
+			var keys []int
+			for i := lo; i < hi; i++ {
+				keys = append(keys, i)
+			}
+			return keys
+		}
+
+		// a clone of one half must survive the other half taking it over
+		l, r := newTree(0, 100).Split(50)
+		snap := l.Clone()
+		r.Join(l)
+		for i := 0; i < 50; i++ {
+			r.Remove(i)
+		}
+		check("split halves", snap, keys(0, 50))
+
+		// a tree that was joined away must not keep owning its old nodes
+		x, y := newTree(0, 50), newTree(50, 100)
+		x.Join(y)
+		snap = x.Clone()
+		y.Insert(1000, 0)
+		y.Join(x)
+		for i := 0; i < 100; i += 2 {
+			y.Remove(i)
+		}
+		check("joined tree", snap, keys(0, 100))
+
+		// nor may a transaction's snapshot be changed under it
+		l, r = newTree(0, 100).Split(50)
+		txn := l.Begin()
+		r.Join(l)
+		for i := 0; i < 50; i++ {
+			r.Remove(i)
+		}
+		if val, found := txn.Get(3); !found || val != 3 {
+			t.Errorf("freelist %v: transaction lost key 3 to a Join", free != nil)
+		}
+	}
+}
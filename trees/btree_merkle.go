@@ -0,0 +1,393 @@
+package trees
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"sort"
+)
+
+// MerkleHash is the SHA-256 hash of a subtree. A node's hash covers its
+// entries and the hashes of its children, so the root hash covers the whole
+// tree and two trees with the same root hash hold the same entries. The
+// hash also depends on the shape of the tree: trees that got the same
+// entries through the same operations at the same order hash the same,
+// trees that hold the same entries in different shapes do not.
+type MerkleHash [sha256.Size]byte
+
+var (
+	ErrNoMerkle     = errors.New("trees: tree does not keep Merkle hashes")
+	ErrInvalidProof = errors.New("trees: invalid Merkle proof")
+)
+
+// merkle hashes nodes, encode turns an entry into the bytes that are hashed
+// and must give equal entries equal bytes
+type merkle[K any, V any] struct {
+	encode func(key K, value V) []byte
+}
+
+// EnableMerkle makes b keep a hash in every node, which costs hashing the
+// nodes on the changed path on every write. encode turns an entry into the
+// bytes that are hashed. Trees that are compared or checked against each
+// other must use the same encoding.
+func (b *BtreeOf[K, V]) EnableMerkle(encode func(key K, value V) []byte) {
+	b.merkle = &merkle[K, V]{encode: encode}
+	b.root = b.resummarize(b.root)
+}
+
+// RootHash returns the hash of the whole tree, or the zero hash if b does
+// not keep hashes
+func (b *BtreeOf[K, V]) RootHash() MerkleHash {
+	if b.merkle == nil {
+		return MerkleHash{}
+	}
+	if b.root == nil {
+		return b.merkle.hash(nil, nil, nil)
+	}
+	return *b.root.hash
+}
+
+func (m *merkle[K, V]) hashNode(node *BtreeNodeOf[K, V]) MerkleHash {
+	var children []MerkleHash
+	if !node.isLeaf {
+		children = make([]MerkleHash, len(node.children))
+		for i, child := range node.children {
+			children[i] = *child.hash
+		}
+	}
+	return m.hash(node.keys, node.values, children)
+}
+
+// hash hashes a node given its entries and the hashes of its children, a
+// leaf has no children. Entries are length prefixed so no two nodes feed
+// the same bytes to the hash.
+func (m *merkle[K, V]) hash(keys []K, values []V, children []MerkleHash) MerkleHash {
+	h := sha256.New()
+	if children == nil {
+		h.Write([]byte{0})
+	} else {
+		h.Write([]byte{1})
+	}
+	for i := range keys {
+		if children != nil {
+			h.Write(children[i][:])
+		}
+		m.writeEntry(h, keys[i], values[i])
+	}
+	if children != nil {
+		h.Write(children[len(keys)][:])
+	}
+	var sum MerkleHash
+	h.Sum(sum[:0])
+	return sum
+}
+
+func (m *merkle[K, V]) writeEntry(h hash.Hash, key K, value V) {
+	entry := m.encode(key, value)
+	h.Write(binary.AppendUvarint(nil, uint64(len(entry))))
+	h.Write(entry)
+}
+
+// MerkleProofOf shows whether a key is in a tree with a known root hash. It
+// holds the nodes on the path the search for Key takes, from the root
+// down. Found tells whether the key is there and Value is its value if so.
+type MerkleProofOf[K any, V any] struct {
+	Key   K
+	Found bool
+	Value V
+	Path  []MerkleProofNodeOf[K, V]
+}
+
+// MerkleProofNodeOf is one node of a proof, its entries and the hashes of
+// its children. Leaves have no children.
+type MerkleProofNodeOf[K any, V any] struct {
+	Keys     []K
+	Values   []V
+	Children []MerkleHash
+}
+
+type MerkleProof = MerkleProofOf[int, any]
+
+type MerkleProofNode = MerkleProofNodeOf[int, any]
+
+// Prove returns a proof that key is in b or that it is not, to be checked
+// against b.RootHash with VerifyMerkleProof
+func (b *BtreeOf[K, V]) Prove(key K) (MerkleProofOf[K, V], error) {
+	proof := MerkleProofOf[K, V]{Key: key}
+	if b.merkle == nil {
+		return proof, ErrNoMerkle
+	}
+	for node := b.root; node != nil; {
+		step := MerkleProofNodeOf[K, V]{
+			Keys:   append([]K(nil), node.keys...),
+			Values: append([]V(nil), node.values...),
+		}
+		if !node.isLeaf {
+			step.Children = make([]MerkleHash, len(node.children))
+			for i, child := range node.children {
+				step.Children[i] = *child.hash
+			}
+		}
+		proof.Path = append(proof.Path, step)
+
+		idx := sort.Search(len(node.keys), func(i int) bool {
+			return b.compare(node.keys[i], key) >= 0
+		})
+		if idx < len(node.keys) && b.compare(node.keys[idx], key) == 0 {
+			proof.Found = true
+			proof.Value = node.values[idx]
+			break
+		}
+		if node.isLeaf {
+			break
+		}
+		node = node.children[idx]
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks that proof is a proof from a tree with the given
+// root hash, ordered by compare and hashed with encode. It returns
+// ErrInvalidProof if the proof does not hash to root or if it does not
+// follow the path a search for its key takes, and nil if proof.Found and
+// proof.Value can be trusted.
+func VerifyMerkleProof[K any, V any](root MerkleHash, proof MerkleProofOf[K, V], compare func(a K, b K) int, encode func(key K, value V) []byte) error {
+	m := &merkle[K, V]{encode: encode}
+	if len(proof.Path) == 0 {
+		if proof.Found || root != m.hash(nil, nil, nil) {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+
+	expected := root
+	for depth, step := range proof.Path {
+		last := depth == len(proof.Path)-1
+		if len(step.Values) != len(step.Keys) || (step.Children != nil && len(step.Children) != len(step.Keys)+1) {
+			return ErrInvalidProof
+		}
+		if m.hash(step.Keys, step.Values, step.Children) != expected {
+			return ErrInvalidProof
+		}
+
+		idx := sort.Search(len(step.Keys), func(i int) bool {
+			return compare(step.Keys[i], proof.Key) >= 0
+		})
+		switch {
+		case idx < len(step.Keys) && compare(step.Keys[idx], proof.Key) == 0:
+			found := encode(step.Keys[idx], step.Values[idx])
+			if !last || !proof.Found || !bytes.Equal(found, encode(proof.Key, proof.Value)) {
+				return ErrInvalidProof
+			}
+		case step.Children == nil:
+			if !last || proof.Found {
+				return ErrInvalidProof
+			}
+		default:
+			if last {
+				return ErrInvalidProof
+			}
+			expected = step.Children[idx]
+		}
+	}
+	return nil
+}
+
+// KeyRangeOf covers the keys from Lo to Hi, both included. A side marked
+// unbounded has no limit.
+type KeyRangeOf[K any] struct {
+	Lo          K
+	Hi          K
+	LoUnbounded bool
+	HiUnbounded bool
+}
+
+type KeyRange = KeyRangeOf[int]
+
+// MerkleDiff returns the key ranges where a and b may differ, in key order.
+// It compares hashes from the roots down and only descends where they
+// differ, so trees that share most of their shape cost about as much as
+// their differences. Separators found in both of two nodes line the nodes
+// up, a stretch between them that is not a single child on both sides is
+// reported whole. Both trees must keep hashes with the same encoding,
+// otherwise everything is reported as different.
+func MerkleDiff[K any, V any](a *BtreeOf[K, V], b *BtreeOf[K, V]) []KeyRangeOf[K] {
+	everything := KeyRangeOf[K]{LoUnbounded: true, HiUnbounded: true}
+	if a.merkle == nil || b.merkle == nil {
+		if a.Len() == 0 && b.Len() == 0 {
+			return nil
+		}
+		return []KeyRangeOf[K]{everything}
+	}
+	if a.RootHash() == b.RootHash() {
+		return nil
+	}
+	var ranges []KeyRangeOf[K]
+	a.merkleDiff(a.root, b.root, everything, &ranges)
+	return ranges
+}
+
+func (b *BtreeOf[K, V]) merkleDiff(x *BtreeNodeOf[K, V], y *BtreeNodeOf[K, V], bounds KeyRangeOf[K], ranges *[]KeyRangeOf[K]) {
+	if x != nil && y != nil && *x.hash == *y.hash {
+		return
+	}
+	if x == nil || y == nil || x.isLeaf != y.isLeaf {
+		*ranges = append(*ranges, bounds)
+		return
+	}
+
+	// i and j walk the keys of x and y, a segment starts after each
+	// separator they share
+	segment := bounds
+	i, j := 0, 0
+	for {
+		fromI, fromJ := i, j
+		for i < len(x.keys) && j < len(y.keys) {
+			c := b.compare(x.keys[i], y.keys[j])
+			if c == 0 {
+				break
+			}
+			if c < 0 {
+				i++
+			} else {
+				j++
+			}
+		}
+		last := i == len(x.keys) || j == len(y.keys)
+		if last {
+			i, j = len(x.keys), len(y.keys)
+			segment.Hi, segment.HiUnbounded = bounds.Hi, bounds.HiUnbounded
+		} else {
+			segment.Hi, segment.HiUnbounded = x.keys[i], false
+		}
+
+		switch {
+		case i != fromI || j != fromJ:
+			*ranges = append(*ranges, segment)
+		case !x.isLeaf:
+			b.merkleDiff(x.children[i], y.children[j], segment, ranges)
+		}
+		if last {
+			return
+		}
+
+		key := x.keys[i]
+		if !bytes.Equal(b.merkle.encode(key, x.values[i]), b.merkle.encode(key, y.values[j])) {
+			*ranges = append(*ranges, KeyRangeOf[K]{Lo: key, Hi: key})
+		}
+		segment.Lo, segment.LoUnbounded = key, false
+		i++
+		j++
+	}
+}
+
+// SyncFrom makes b hold the same entries as src. When both trees keep
+// hashes and have the same order, b takes over the subtrees of src that
+// MerkleDiff would report instead of copying their entries, and ends up
+// with the shape and root hash of src. Like Clone, src stops owning its
+// nodes so the trees can share them. Otherwise the entries of the reported
+// ranges are copied one by one.
+func (b *BtreeOf[K, V]) SyncFrom(src *BtreeOf[K, V]) {
+	if b == src {
+		return
+	}
+	if b.merkle == nil || src.merkle == nil || b.order != src.order {
+		b.copyRanges(src, MerkleDiff(b, src))
+		return
+	}
+	if b.RootHash() == src.RootHash() {
+		return
+	}
+
+	var events []EventOf[K, V]
+	if b.feed.active() {
+		left := newDiffCursor(b.root, b.height)
+		right := newDiffCursor(src.root, src.height)
+		diffCursors(b.compare, left, right, func(key K, x V, y V) bool {
+			return bytes.Equal(b.merkle.encode(key, x), b.merkle.encode(key, y))
+		}, func(d DiffEntryOf[K, V]) bool {
+			kind := EventUpdate
+			if d.Kind == DiffAdded {
+				kind = EventInsert
+			} else if d.Kind == DiffRemoved {
+				kind = EventDelete
+			}
+			events = append(events, EventOf[K, V]{Kind: kind, Key: d.Key, Old: d.Old, New: d.New})
+			return true
+		})
+	}
+
+	src.cow = &copyOnWrite{}
+	if b.height != src.height {
+		b.root = b.adopt(src, src.root)
+	} else {
+		b.root = b.graft(src, b.root, src.root)
+	}
+	b.height, b.size = src.height, src.size
+	b.feed.publish(events)
+}
+
+// graft makes the subtree at node equal to from, taking over the subtrees
+// of from where the two part ways
+func (b *BtreeOf[K, V]) graft(src *BtreeOf[K, V], node *BtreeNodeOf[K, V], from *BtreeNodeOf[K, V]) *BtreeNodeOf[K, V] {
+	if node == nil || from == nil || node.isLeaf != from.isLeaf || len(node.keys) != len(from.keys) {
+		return b.adopt(src, from)
+	}
+	if *node.hash == *from.hash {
+		return node
+	}
+	for i := range node.keys {
+		if b.compare(node.keys[i], from.keys[i]) != 0 {
+			return b.adopt(src, from)
+		}
+	}
+	node = b.mutable(node)
+	copy(node.values, from.values)
+	for i := range node.children {
+		node.children[i] = b.graft(src, node.children[i], from.children[i])
+	}
+	b.updateNode(node)
+	return node
+}
+
+// adopt returns a subtree of src for b to use, summarized for b
+func (b *BtreeOf[K, V]) adopt(src *BtreeOf[K, V], node *BtreeNodeOf[K, V]) *BtreeNodeOf[K, V] {
+	if !sameMonoid(b.monoid, src.monoid) {
+		return b.resummarize(node)
+	}
+	return node
+}
+
+// copyRanges replaces the entries of b in each range with those of src
+func (b *BtreeOf[K, V]) copyRanges(src *BtreeOf[K, V], ranges []KeyRangeOf[K]) {
+	for _, r := range ranges {
+		var stale []K
+		b.ascendKeyRange(r, func(key K, value V) bool {
+			stale = append(stale, key)
+			return true
+		})
+		for _, key := range stale {
+			b.Remove(key)
+		}
+		src.ascendKeyRange(r, func(key K, value V) bool {
+			b.add(key, value)
+			return true
+		})
+	}
+}
+
+// ascendKeyRange calls fn for the entries in r in key order
+func (b *BtreeOf[K, V]) ascendKeyRange(r KeyRangeOf[K], fn func(key K, value V) bool) {
+	stop := func(key K) bool {
+		return !r.HiUnbounded && b.compare(key, r.Hi) > 0
+	}
+	if r.LoUnbounded {
+		b.ascend(b.root, func(key K, value V) bool {
+			return !stop(key) && fn(key, value)
+		})
+		return
+	}
+	b.ascendFrom(b.root, r.Lo, stop, fn)
+}
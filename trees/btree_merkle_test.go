@@ -0,0 +1,286 @@
+package trees
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func encodeEntry(key int, value any) []byte {
+	return fmt.Appendf(nil, "%d=%v", key, value)
+}
+
+// checkMerkle rehashes every node from scratch and compares the result
+// with the hash the node keeps
+func checkMerkle(t *testing.T, b *Btree) {
+	t.Helper()
+	var rehash func(node *BtreeNode) MerkleHash
+	rehash = func(node *BtreeNode) MerkleHash {
+		var children []MerkleHash
+		for _, child := range node.children {
+			children = append(children, rehash(child))
+		}
+		h := b.merkle.hash(node.keys, node.values, children)
+		if h != *node.hash {
+			t.Fatalf("node %v keeps a stale hash", node.keys)
+		}
+		return h
+	}
+	if b.root != nil {
+		rehash(b.root)
+	}
+}
+
+func TestBtree_MerkleHashes(t *testing.T) {
+	if got := NewBtree(3).RootHash(); got != (MerkleHash{}) {
+		t.Errorf("RootHash without hashes = %x, want zero", got)
+	}
+	if plain := newBtreeWithKeys(3, 1, 2, 3, 4, 5); plain.root.hash != nil || plain.root.children[0].hash != nil {
+		t.Errorf("nodes of a tree without hashes should not keep one")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	a := NewBtree(4)
+	for i := 0; i < 50; i++ {
+		a.Insert(i, i)
+	}
+	a.EnableMerkle(encodeEntry)
+	b := NewBtree(4)
+	b.EnableMerkle(encodeEntry)
+	for i := 0; i < 50; i++ {
+		b.Insert(i, i)
+	}
+	if a.RootHash() != b.RootHash() {
+		t.Fatalf("trees built the same way hash differently")
+	}
+
+	for round := 0; round < 300; round++ {
+		key := rng.Intn(200)
+		switch rng.Intn(6) {
+		case 0:
+			a.Remove(key)
+		case 1:
+			a.Put(key, rng.Intn(3))
+		case 2:
+			a.DeleteRange(key, key+rng.Intn(10))
+		case 3:
+			left, right := a.Split(key)
+			left.Join(right)
+			a = left
+		case 4:
+			a.Update(func(txn *TxnOf[int, any]) error {
+				txn.Put(key, "txn")
+				return nil
+			})
+		default:
+			a.Insert(key, key)
+		}
+		checkMerkle(t, a)
+	}
+
+	snapshot := a.Clone()
+	before := a.RootHash()
+	key, value, _ := a.Min()
+	a.Put(key, "changed")
+	checkMerkle(t, a)
+	checkMerkle(t, snapshot)
+	if a.RootHash() == before || snapshot.RootHash() != before {
+		t.Errorf("a change to a clone must change its hash and only its hash")
+	}
+	a.Put(key, value)
+	if a.RootHash() != before {
+		t.Errorf("undoing a change in place should restore the hash")
+	}
+
+	a.Clear()
+	if a.RootHash() != newMerkleBtree(3).RootHash() {
+		t.Errorf("empty trees hash differently")
+	}
+}
+
+func newMerkleBtree(order int) *Btree {
+	b := NewBtree(order)
+	b.EnableMerkle(encodeEntry)
+	return b
+}
+
+func TestBtree_MerkleProof(t *testing.T) {
+	if _, err := NewBtree(3).Prove(1); !errors.Is(err, ErrNoMerkle) {
+		t.Errorf("Prove without hashes returned %v, want ErrNoMerkle", err)
+	}
+	verify := func(root MerkleHash, proof MerkleProof) error {
+		return VerifyMerkleProof(root, proof, compareInts, encodeEntry)
+	}
+
+	empty := newMerkleBtree(3)
+	proof, _ := empty.Prove(5)
+	if proof.Found || verify(empty.RootHash(), proof) != nil {
+		t.Errorf("proof of absence from an empty tree does not verify")
+	}
+
+	b := newMerkleBtree(3)
+	for i := 0; i < 200; i += 2 {
+		b.Insert(i, i*10)
+	}
+	root := b.RootHash()
+	for key := -1; key <= 200; key++ {
+		proof, err := b.Prove(key)
+		if err != nil {
+			t.Fatalf("Prove(%d): %v", key, err)
+		}
+		if proof.Found != (key >= 0 && key%2 == 0 && key < 200) {
+			t.Errorf("Prove(%d).Found = %v", key, proof.Found)
+		}
+		if proof.Found && proof.Value != key*10 {
+			t.Errorf("Prove(%d).Value = %v", key, proof.Value)
+		}
+		if err := verify(root, proof); err != nil {
+			t.Errorf("proof for %d does not verify: %v", key, err)
+		}
+	}
+
+	proof, _ = b.Prove(42)
+	tests := []struct {
+		name   string
+		tamper func(p *MerkleProof)
+	}{
+		{"lie about the value", func(p *MerkleProof) { p.Value = 1 }},
+		{"lie about absence", func(p *MerkleProof) { p.Found = false }},
+		{"swap the key", func(p *MerkleProof) { p.Key = 43 }},
+		{"change an entry on the path", func(p *MerkleProof) { p.Path[0].Values[0] = -1 }},
+		{"drop the last node", func(p *MerkleProof) { p.Path = p.Path[:len(p.Path)-1] }},
+		{"drop the root", func(p *MerkleProof) { p.Path = p.Path[1:] }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fresh, _ := b.Prove(42)
+			tc.tamper(&fresh)
+			if err := verify(root, fresh); !errors.Is(err, ErrInvalidProof) {
+				t.Errorf("tampered proof verified: %v", err)
+			}
+		})
+	}
+	b.Put(42, 0)
+	if err := verify(b.RootHash(), proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("stale proof verified against the new root")
+	}
+}
+
+func compareInts(a int, b int) int {
+	return a - b
+}
+
+func TestBtree_MerkleSync(t *testing.T) {
+	primary := newMerkleBtree(8)
+	for i := 0; i < 5000; i++ {
+		primary.Insert(i, i)
+	}
+	replica := primary.Clone()
+	if ranges := MerkleDiff(primary, replica); ranges != nil {
+		t.Fatalf("MerkleDiff of a fresh clone = %v, want none", ranges)
+	}
+
+	primary.Put(10, "new")
+	primary.Remove(2500)
+	primary.Insert(9000, 9000)
+	replica.Put(4000, "stale")
+
+	ranges := MerkleDiff(replica, primary)
+	if len(ranges) == 0 || len(ranges) > 8 {
+		t.Errorf("MerkleDiff found %d ranges, want a few: %v", len(ranges), ranges)
+	}
+	var events []Event
+	replica.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+	replica.SyncFrom(primary)
+	expected := []Event{
+		{Seq: 1, Kind: EventUpdate, Key: 10, Old: 10, New: "new"},
+		{Seq: 2, Kind: EventDelete, Key: 2500, Old: 2500},
+		{Seq: 3, Kind: EventUpdate, Key: 4000, Old: "stale", New: 4000},
+		{Seq: 4, Kind: EventInsert, Key: 9000, New: 9000},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("SyncFrom events = %v, want %v", events, expected)
+	}
+	if !reflect.DeepEqual(btreeEntries(replica), btreeEntries(primary)) {
+		t.Fatalf("replica differs from primary after sync")
+	}
+	checkBtree(t, replica)
+	checkMerkle(t, replica)
+	if replica.RootHash() != primary.RootHash() {
+		t.Errorf("replica should take the shape and hash of primary")
+	}
+
+	// the trees share nodes now, writes to one must not reach the other
+	primary.Put(20, "later")
+	checkMerkle(t, primary)
+	checkMerkle(t, replica)
+	if v, _ := replica.Get(20); v != 20 {
+		t.Errorf("write to primary reached replica")
+	}
+	if ranges := MerkleDiff(replica, primary); len(ranges) != 1 || ranges[0].Lo != 20 || ranges[0].Hi != 20 {
+		t.Errorf("MerkleDiff after one write = %v, want [20, 20]", ranges)
+	}
+
+	// trees of different shapes still sync, they just compare more
+	other := newMerkleBtree(3)
+	for i := 100; i < 300; i++ {
+		other.Insert(i, -i)
+	}
+	other.SyncFrom(primary)
+	if !reflect.DeepEqual(btreeEntries(other), btreeEntries(primary)) {
+		t.Errorf("differently shaped replica differs from primary after sync")
+	}
+	if got := MerkleDiff(primary, NewBtree(3)); len(got) != 1 || !got[0].LoUnbounded || !got[0].HiUnbounded {
+		t.Errorf("MerkleDiff with a tree without hashes = %v, want everything", got)
+	}
+}
+
+func TestBtree_MerkleSyncRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for round := 0; round < 100; round++ {
+		order := 3 + rng.Intn(5)
+		src := newMerkleBtree(order)
+		dst := newMerkleBtree(order)
+		dst.SetMonoid(keysMonoid())
+		for n := rng.Intn(400); n > 0; n-- {
+			src.Put(rng.Intn(300), rng.Intn(3))
+		}
+		if rng.Intn(2) == 0 {
+			dst = src.Clone()
+			dst.SetMonoid(keysMonoid())
+		}
+		for n := rng.Intn(400); n > 0; n-- {
+			if rng.Intn(3) == 0 {
+				dst.Remove(rng.Intn(300))
+			} else {
+				dst.Put(rng.Intn(300), rng.Intn(3))
+			}
+		}
+
+		dst.SyncFrom(src)
+		if !reflect.DeepEqual(btreeEntries(dst), btreeEntries(src)) || dst.RootHash() != src.RootHash() {
+			t.Fatalf("round %d: dst differs from src after sync", round)
+		}
+		checkBtreeSummaries(t, dst)
+
+		// both trees keep working on their own
+		for n := 0; n < 50; n++ {
+			src.Put(rng.Intn(300), "src")
+			dst.Remove(rng.Intn(300))
+		}
+		checkBtree(t, src)
+		checkBtree(t, dst)
+		checkMerkle(t, src)
+		checkMerkle(t, dst)
+		checkBtreeSummaries(t, dst)
+		for key, value := range dst.All() {
+			if value == "src" {
+				t.Fatalf("round %d: write to src reached dst at key %d", round, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package trees
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"sync"
+)
+
+var ErrVersionCompacted = errors.New("trees: version has been garbage collected")
+
+// MVCCBtreeOf is a B-tree that keeps its past versions readable. Every write
+// creates a new version, numbered one higher than the last, and GetAt and
+// RangeAt read the tree as it was at any version that has not been garbage
+// collected. Versions are copy-on-write clones, so each one only costs the
+// nodes along the paths its write changed. It is safe for concurrent use and
+// reads of past versions never wait for writers.
+type MVCCBtreeOf[K any, V any] struct {
+	mu       sync.RWMutex
+	current  *BtreeOf[K, V]
+	versions *BtreeOf[uint64, *BtreeOf[K, V]] // a frozen clone per version
+	version  uint64
+	oldest   uint64 // the oldest version that can still be read
+}
+
+type MVCCBtree = MVCCBtreeOf[int, any]
+
+func NewMVCCBtree(order int) *MVCCBtree {
+	return NewMVCCBtreeOf[int, any](order, cmp.Compare[int])
+}
+
+func NewMVCCBtreeOf[K any, V any](order int, compare func(a K, b K) int) *MVCCBtreeOf[K, V] {
+	m := &MVCCBtreeOf[K, V]{
+		current:  NewBtreeOf[K, V](order, compare),
+		versions: NewBtreeOf[uint64, *BtreeOf[K, V]](order, cmp.Compare[uint64]),
+	}
+	m.versions.Put(0, m.current.Clone())
+	return m
+}
+
+// publish freezes the current tree as the next version
+func (m *MVCCBtreeOf[K, V]) publish() uint64 {
+	m.version++
+	m.versions.Put(m.version, m.current.Clone())
+	return m.version
+}
+
+// Put stores value under key, replacing any value it had, and returns the
+// version the write created
+func (m *MVCCBtreeOf[K, V]) Put(key K, value V) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current.Put(key, value)
+	return m.publish()
+}
+
+// Delete removes key and returns the version the write created. Deleting a
+// missing key creates no version and reports the current one.
+func (m *MVCCBtreeOf[K, V]) Delete(key K) (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.current.Remove(key) {
+		return m.version, false
+	}
+	return m.publish(), true
+}
+
+// Update applies the writes fn stages in a transaction as a single version.
+// Nothing is written and no version is created when fn returns an error or
+// stages nothing.
+func (m *MVCCBtreeOf[K, V]) Update(fn func(txn *TxnOf[K, V]) error) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txn := m.current.Begin()
+	defer txn.Rollback()
+	if err := fn(txn); err != nil {
+		return m.version, err
+	}
+	if len(txn.ops) == 0 {
+		return m.version, nil
+	}
+	if err := txn.Commit(); err != nil {
+		return m.version, err
+	}
+	return m.publish(), nil
+}
+
+// Version returns the latest version
+func (m *MVCCBtreeOf[K, V]) Version() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.version
+}
+
+// at returns the frozen tree for version. Versions past the latest read the
+// latest one.
+func (m *MVCCBtreeOf[K, V]) at(version uint64) (*BtreeOf[K, V], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if version < m.oldest {
+		return nil, ErrVersionCompacted
+	}
+	_, tree, _ := m.versions.Floor(version)
+	return tree, nil
+}
+
+// Get reads key at the latest version
+func (m *MVCCBtreeOf[K, V]) Get(key K) (V, bool) {
+	value, found, _ := m.GetAt(key, m.Version())
+	return value, found
+}
+
+// GetAt reads key as it was at version
+func (m *MVCCBtreeOf[K, V]) GetAt(key K, version uint64) (V, bool, error) {
+	tree, err := m.at(version)
+	if err != nil {
+		var zero V
+		return zero, false, err
+	}
+	value, found := tree.Get(key)
+	return value, found, nil
+}
+
+// RangeAt yields the entries with lo <= key < hi as they were at version.
+// The version is pinned when RangeAt is called, writes made while the loop
+// runs do not show up in it.
+func (m *MVCCBtreeOf[K, V]) RangeAt(lo K, hi K, version uint64) (iter.Seq2[K, V], error) {
+	tree, err := m.at(version)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Range(lo, hi), nil
+}
+
+// Len returns the number of entries at the latest version
+func (m *MVCCBtreeOf[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current.Len()
+}
+
+// GC drops the versions older than watermark that no read at or after
+// watermark needs and returns how many it dropped. Afterwards reads of a
+// version older than the oldest one kept fail with ErrVersionCompacted.
+func (m *MVCCBtreeOf[K, V]) GC(watermark uint64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if watermark <= m.oldest {
+		return 0
+	}
+	watermark = min(watermark, m.version)
+
+	// the newest version at or below watermark is still what reads at
+	// watermark see, everything before it can go
+	keep, _, _ := m.versions.Floor(watermark)
+	dropped := m.versions.DeleteRange(0, keep)
+	m.oldest = keep
+	return dropped
+}
@@ -0,0 +1,181 @@
+package trees
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMVCCBtree_ReadAtVersion(t *testing.T) {
+	m := NewMVCCBtree(3)
+	v1 := m.Put(1, "a")
+	v2 := m.Put(2, "b")
+	v3 := m.Put(1, "c")
+	v4, _ := m.Delete(2)
+	if _, ok := m.Delete(42); ok {
+		t.Errorf("Delete(42) should report false for a missing key")
+	}
+	if v1 != 1 || v2 != 2 || v3 != 3 || v4 != 4 || m.Version() != 4 {
+		t.Fatalf("versions = %d %d %d %d, latest %d", v1, v2, v3, v4, m.Version())
+	}
+
+	tests := []struct {
+		key     int
+		version uint64
+		want    any
+		found   bool
+	}{
+		{1, 0, nil, false},
+		{1, 1, "a", true},
+		{1, 2, "a", true},
+		{1, 3, "c", true},
+		{2, 1, nil, false},
+		{2, 2, "b", true},
+		{2, 3, "b", true},
+		{2, 4, nil, false},
+		{1, 100, "c", true},
+	}
+	for _, tt := range tests {
+		val, found, err := m.GetAt(tt.key, tt.version)
+		if err != nil || val != tt.want || found != tt.found {
+			t.Errorf("GetAt(%d, %d) = %v, %v, %v, want %v, %v", tt.key, tt.version, val, found, err, tt.want, tt.found)
+		}
+	}
+	if val, _ := m.Get(1); val != "c" || m.Len() != 1 {
+		t.Errorf("Get(1) = %v, Len() = %d at the latest version", val, m.Len())
+	}
+
+	entries, err := m.RangeAt(0, 10, 3)
+	if err != nil {
+		t.Fatalf("RangeAt: %v", err)
+	}
+	m.Put(3, "d")
+	if got := maps.Collect(entries); !reflect.DeepEqual(got, map[int]any{1: "c", 2: "b"}) {
+		t.Errorf("RangeAt(0, 10, 3) = %v", got)
+	}
+}
+
+func TestMVCCBtree_Update(t *testing.T) {
+	m := NewMVCCBtree(4)
+	m.Put(1, 1)
+
+	v, err := m.Update(func(txn *Txn) error {
+		txn.Put(2, 2)
+		txn.Put(3, 3)
+		txn.Delete(1)
+		return nil
+	})
+	if err != nil || v != 2 {
+		t.Fatalf("Update = %d, %v, want version 2", v, err)
+	}
+	if _, found, _ := m.GetAt(1, 1); !found {
+		t.Errorf("key 1 should still be visible at version 1")
+	}
+	entries, _ := m.RangeAt(0, 10, 2)
+	if got := maps.Collect(entries); !reflect.DeepEqual(got, map[int]any{2: 2, 3: 3}) {
+		t.Errorf("version 2 = %v, the batch should land as one version", got)
+	}
+
+	fail := errors.New("fail")
+	if v, err := m.Update(func(txn *Txn) error {
+		txn.Put(9, 9)
+		return fail
+	}); !errors.Is(err, fail) || v != 2 {
+		t.Errorf("failed Update = %d, %v", v, err)
+	}
+	if v, _ := m.Update(func(txn *Txn) error { return nil }); v != 2 || m.Version() != 2 {
+		t.Errorf("an empty Update should not create a version")
+	}
+}
+
+func TestMVCCBtree_GC(t *testing.T) {
+	m := NewMVCCBtree(3)
+	for i := 1; i <= 10; i++ {
+		m.Put(i%3, i)
+	}
+
+	if dropped := m.GC(6); dropped != 6 {
+		t.Errorf("GC(6) dropped %d versions, want 6", dropped)
+	}
+	if _, _, err := m.GetAt(0, 5); !errors.Is(err, ErrVersionCompacted) {
+		t.Errorf("GetAt below the watermark: expected ErrVersionCompacted, got %v", err)
+	}
+	if _, err := m.RangeAt(0, 3, 0); !errors.Is(err, ErrVersionCompacted) {
+		t.Errorf("RangeAt below the watermark: expected ErrVersionCompacted, got %v", err)
+	}
+	if val, _, err := m.GetAt(0, 6); err != nil || val != 6 {
+		t.Errorf("GetAt(0, 6) = %v, %v after GC(6)", val, err)
+	}
+	if val, _, _ := m.GetAt(1, 6); val != 4 {
+		t.Errorf("GetAt(1, 6) = %v, want 4", val)
+	}
+
+	if m.GC(3) != 0 {
+		t.Errorf("GC below the current watermark should drop nothing")
+	}
+	m.GC(1000)
+	if val, _, err := m.GetAt(1, m.Version()); err != nil || val != 10 {
+		t.Errorf("the latest version must survive any watermark, got %v, %v", val, err)
+	}
+}
+
+func TestMVCCBtree_GCKeepsFloorVersion(t *testing.T) {
+	m := NewMVCCBtree(3)
+	for i := 1; i <= 10; i++ {
+		m.Put(i, i)
+	}
+	// with no versions stored between 3 and 7, version 3 is what reads at 4
+	// to 6 see and GC(6) has to keep it readable
+	for v := uint64(4); v <= 6; v++ {
+		m.versions.Remove(v)
+	}
+
+	if dropped := m.GC(6); dropped != 3 {
+		t.Errorf("GC(6) dropped %d versions, want 3", dropped)
+	}
+	if val, found, err := m.GetAt(3, 5); err != nil || !found || val != 3 {
+		t.Errorf("GetAt(3, 5) = %v, %v, %v after GC(6), want 3", val, found, err)
+	}
+	if _, found, err := m.GetAt(4, 5); err != nil || found {
+		t.Errorf("GetAt(4, 5) = %v, %v after GC(6), want not found", found, err)
+	}
+	if _, _, err := m.GetAt(3, 2); !errors.Is(err, ErrVersionCompacted) {
+		t.Errorf("GetAt below the oldest kept version: expected ErrVersionCompacted, got %v", err)
+	}
+}
+
+func TestMVCCBtree_ConcurrentReaders(t *testing.T) {
+	m := NewMVCCBtree(8)
+	for i := 0; i < 100; i++ {
+		m.Put(i, 0)
+	}
+	base := m.Version()
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				entries, err := m.RangeAt(0, 100, base)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for key, val := range entries {
+					if val != 0 {
+						t.Errorf("key %d = %v at version %d", key, val, base)
+						return
+					}
+				}
+				m.Get(i % 100)
+			}
+		}()
+	}
+	for i := 1; i <= 500; i++ {
+		m.Put(i%100, i)
+	}
+	wg.Wait()
+}
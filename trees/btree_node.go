@@ -0,0 +1,151 @@
+package trees
+
+import (
+	"sync"
+)
+
+// Nodes are allocated with room for maxKeys+1 keys and values and one more
+// child, which covers the one key overflow insert allows before splitting.
+// Shifts then happen in place and only joins, which can briefly build bigger
+// nodes, grow the slices.
+
+// DefaultFreeListSize is the number of nodes a free list keeps by default
+const DefaultFreeListSize = 32
+
+// FreeListOf keeps nodes that trees have let go of so new nodes can reuse
+// them and their slices instead of allocating. It can be shared by trees
+// with the same key type and is safe for concurrent use.
+type FreeListOf[K any, V any] struct {
+	mu    sync.Mutex
+	nodes []*BtreeNodeOf[K, V]
+}
+
+type FreeList = FreeListOf[int, any]
+
+func NewFreeList(size int) *FreeList {
+	return NewFreeListOf[int, any](size)
+}
+
+// NewFreeListOf creates a free list that keeps up to size nodes, a size <= 0
+// uses DefaultFreeListSize
+func NewFreeListOf[K any, V any](size int) *FreeListOf[K, V] {
+	if size <= 0 {
+		size = DefaultFreeListSize
+	}
+	return &FreeListOf[K, V]{nodes: make([]*BtreeNodeOf[K, V], 0, size)}
+}
+
+func (f *FreeListOf[K, V]) get() *BtreeNodeOf[K, V] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.nodes) == 0 {
+		return nil
+	}
+	last := len(f.nodes) - 1
+	node := f.nodes[last]
+	f.nodes[last] = nil
+	f.nodes = f.nodes[:last]
+	return node
+}
+
+func (f *FreeListOf[K, V]) put(node *BtreeNodeOf[K, V]) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.nodes) < cap(f.nodes) {
+		f.nodes = append(f.nodes, node)
+	}
+}
+
+// SetFreeList makes the tree take new nodes from f and hand the nodes it
+// drops back to it, a nil f goes back to allocating every node
+func (b *BtreeOf[K, V]) SetFreeList(f *FreeListOf[K, V]) {
+	b.freelist = f
+}
+
+// allocNode returns an empty node, from the free list when it has one
+func (b *BtreeOf[K, V]) allocNode(isLeaf bool) *BtreeNodeOf[K, V] {
+	var node *BtreeNodeOf[K, V]
+	if b.freelist != nil {
+		node = b.freelist.get()
+	}
+	if node == nil {
+		node = &BtreeNodeOf[K, V]{
+			keys:   make([]K, 0, b.maxKeys+1),
+			values: make([]V, 0, b.maxKeys+1),
+		}
+	}
+	node.isLeaf = isLeaf
+	node.cow = b.cow
+	if !isLeaf && node.children == nil {
+		node.children = make([]*BtreeNodeOf[K, V], 0, b.maxKeys+2)
+	}
+	return node
+}
+
+// freeNode hands a node the tree dropped to the free list, unless another
+// tree may still be using it
+func (b *BtreeOf[K, V]) freeNode(node *BtreeNodeOf[K, V]) {
+	if b.freelist == nil || node.cow != b.cow {
+		return
+	}
+	clear(node.keys)
+	clear(node.values)
+	clear(node.children)
+	node.keys = node.keys[:0]
+	node.values = node.values[:0]
+	node.children = node.children[:0]
+	node.count = 0
+	node.agg = nil
+	node.hash = nil
+	node.cow = nil
+	b.freelist.put(node)
+}
+
+// copyOnWrite marks which tree owns a node. A tree only changes nodes it
+// owns in place and copies any other node before changing it, so trees can
+// share nodes after Clone. It is not empty because pointers to distinct
+// zero-size values may compare equal.
+type copyOnWrite struct {
+	_ byte
+}
+
+// Clone returns a copy of the tree in O(1). Both trees share their nodes
+// until one of them changes, and then only the nodes along the changed path
+// are copied, so a clone also works as a cheap read-only snapshot. The clone
+// starts without subscribers. Cloning takes away b's ownership of its nodes,
+// so it counts as a write to b.
+func (b *BtreeOf[K, V]) Clone() *BtreeOf[K, V] {
+	t := *b
+	t.feed = nil
+	// neither tree owns the shared nodes anymore
+	b.cow = &copyOnWrite{}
+	t.cow = &copyOnWrite{}
+	return &t
+}
+
+// mutable returns node if the tree owns it and a copy the tree owns
+// otherwise
+func (b *BtreeOf[K, V]) mutable(node *BtreeNodeOf[K, V]) *BtreeNodeOf[K, V] {
+	if node == nil || node.cow == b.cow {
+		return node
+	}
+	c := b.allocNode(node.isLeaf)
+	c.keys = append(c.keys, node.keys...)
+	c.values = append(c.values, node.values...)
+	c.children = append(c.children, node.children...)
+	c.count = node.count
+	c.agg = node.agg
+	if node.hash != nil {
+		// the copy gets its own hash, updateNode rewrites it in place
+		hash := *node.hash
+		c.hash = &hash
+	}
+	return c
+}
+
+// mutableChild makes child i of an owned node owned as well
+func (b *BtreeOf[K, V]) mutableChild(node *BtreeNodeOf[K, V], i int) *BtreeNodeOf[K, V] {
+	child := b.mutable(node.children[i])
+	node.children[i] = child
+	return child
+}
@@ -0,0 +1,133 @@
+package trees
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBtree_NodeCapacity(t *testing.T) {
+	for _, order := range []int{3, 4, 7, 16} {
+		b := NewBtree(order)
+		for _, key := range rand.New(rand.NewSource(1)).Perm(2000) {
+			b.Insert(key, key)
+		}
+
+		var check func(node *BtreeNode)
+		check = func(node *BtreeNode) {
+			if cap(node.keys) != b.maxKeys+1 || cap(node.values) != b.maxKeys+1 {
+				t.Fatalf("order %d: node has capacity %d/%d, want %d", order, cap(node.keys), cap(node.values), b.maxKeys+1)
+			}
+			for _, child := range node.children {
+				check(child)
+			}
+		}
+		check(b.root)
+	}
+}
+
+func TestBtree_FreeList(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		free := NewFreeList(8)
+		b := NewBtree(order)
+		b.SetFreeList(free)
+
+		rng := rand.New(rand.NewSource(int64(order)))
+		keys := rng.Perm(1000)
+		for _, key := range keys {
+			b.Insert(key, key)
+		}
+		for _, key := range keys[:900] {
+			b.Remove(key)
+		}
+		checkBtree(t, b)
+		if len(free.nodes) == 0 {
+			t.Fatalf("order %d: removing entries should hand nodes to the free list", order)
+		}
+		for _, node := range free.nodes {
+			if len(node.keys) != 0 || len(node.children) != 0 || node.count != 0 {
+				t.Fatalf("order %d: free list holds a node that was not reset", order)
+			}
+		}
+
+		// the reused nodes must behave like fresh ones
+		for _, key := range keys[:900] {
+			b.Insert(key, key)
+		}
+		checkBtree(t, b)
+		for _, key := range keys {
+			if val, found := b.Get(key); !found || val != key {
+				t.Fatalf("order %d: Get(%d) = %v, %v after reusing nodes", order, key, val, found)
+			}
+		}
+	}
+}
+
+func TestFreeList_Bounded(t *testing.T) {
+	free := NewFreeList(2)
+	for i := 0; i < 5; i++ {
+		free.put(&BtreeNode{})
+	}
+	if len(free.nodes) != 2 {
+		t.Errorf("free list holds %d nodes, want at most 2", len(free.nodes))
+	}
+	if free.get() == nil || free.get() == nil || free.get() != nil {
+		t.Errorf("get should hand out the 2 kept nodes and then nil")
+	}
+	if cap(NewFreeList(0).nodes) != DefaultFreeListSize {
+		t.Errorf("NewFreeList(0) should use DefaultFreeListSize")
+	}
+}
+
+func TestBtree_CloneIsolation(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		rng := rand.New(rand.NewSource(int64(order)))
+		free := NewFreeList(16)
+		a := NewBtree(order)
+		a.SetFreeList(free)
+		a.SetMonoid(keysMonoid())
+		for _, key := range rng.Perm(300) {
+			a.Put(key, key)
+		}
+
+		trees := []*Btree{a}
+		models := []map[int]any{btreeEntries(a)}
+		for step := 0; step < 400; step++ {
+			i := rng.Intn(len(trees))
+			if len(trees) < 6 && rng.Intn(20) == 0 {
+				trees = append(trees, trees[i].Clone())
+				models = append(models, btreeEntries(trees[i]))
+				continue
+			}
+
+			tree, model := trees[i], models[i]
+			key := rng.Intn(400)
+			switch rng.Intn(4) {
+			case 0:
+				tree.Put(key, step)
+				model[key] = step
+			case 1:
+				tree.Remove(key)
+				delete(model, key)
+			case 2:
+				tree.DeleteRange(key, key+10)
+				for k := key; k < key+10; k++ {
+					delete(model, k)
+				}
+			case 3:
+				if _, found := model[key]; !found {
+					tree.Insert(key, step)
+					model[key] = step
+				}
+			}
+		}
+
+		for i, tree := range trees {
+			checkBtree(t, tree)
+			checkBtreeSummaries(t, tree)
+			if got := btreeEntries(tree); !reflect.DeepEqual(got, models[i]) {
+				t.Fatalf("order %d: tree %d drifted from its model", order, i)
+			}
+		}
+	}
+}
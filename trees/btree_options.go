@@ -0,0 +1,128 @@
+package trees
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidOrder   = errors.New("trees: invalid order")
+	ErrInvalidOptions = errors.New("trees: invalid options")
+	ErrKeyNotFound    = errors.New("trees: key not found")
+	ErrDuplicateKey   = errors.New("trees: duplicate key")
+)
+
+// DuplicatePolicy decides what inserting a key that is already in a tree
+// does
+type DuplicatePolicy int
+
+const (
+	// AllowDuplicates adds another entry with the same key
+	AllowDuplicates DuplicatePolicy = iota
+	// ReplaceDuplicates replaces the value of the entry, like Put
+	ReplaceDuplicates
+	// RejectDuplicates leaves the tree as it is, TryInsert reports
+	// ErrDuplicateKey
+	RejectDuplicates
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case AllowDuplicates:
+		return "allow"
+	case ReplaceDuplicates:
+		return "replace"
+	case RejectDuplicates:
+		return "reject"
+	}
+	return "unknown"
+}
+
+// BtreeOptionsOf configures a tree made by NewBtreeWithOptionsOf
+type BtreeOptionsOf[K any] struct {
+	// Order is the most children a node may have, at least 3
+	Order int
+	// Compare orders the keys and must be set
+	Compare func(a K, b K) int
+	// FillFactor is how full bulk operations such as BulkLoad, DeleteFunc
+	// and the set operations pack nodes, between 0 and 1. Zero packs them
+	// full. Nodes never get less than half full.
+	FillFactor float64
+	// Duplicates decides what Insert does with a key already in the tree
+	Duplicates DuplicatePolicy
+}
+
+type BtreeOptions = BtreeOptionsOf[int]
+
+// NewBtreeWithOptions is NewBtreeWithOptionsOf for int keys, a nil Compare
+// orders them as numbers
+func NewBtreeWithOptions(opts BtreeOptions) (*Btree, error) {
+	if opts.Compare == nil {
+		opts.Compare = cmp.Compare[int]
+	}
+	return NewBtreeWithOptionsOf[int, any](opts)
+}
+
+// NewBtreeWithOptionsOf returns an empty tree configured by opts. Unlike
+// NewBtreeOf it does not fix up bad options but reports them, with an
+// error matching ErrInvalidOrder for the order and ErrInvalidOptions for
+// the rest.
+func NewBtreeWithOptionsOf[K any, V any](opts BtreeOptionsOf[K]) (*BtreeOf[K, V], error) {
+	if opts.Order < 3 {
+		return nil, fmt.Errorf("%w: %d, must be at least 3", ErrInvalidOrder, opts.Order)
+	}
+	if opts.Compare == nil {
+		return nil, fmt.Errorf("%w: Compare must be set", ErrInvalidOptions)
+	}
+	if !(opts.FillFactor >= 0 && opts.FillFactor <= 1) {
+		return nil, fmt.Errorf("%w: FillFactor %v is not between 0 and 1", ErrInvalidOptions, opts.FillFactor)
+	}
+	switch opts.Duplicates {
+	case AllowDuplicates, ReplaceDuplicates, RejectDuplicates:
+	default:
+		return nil, fmt.Errorf("%w: unknown DuplicatePolicy %d", ErrInvalidOptions, opts.Duplicates)
+	}
+
+	b := NewBtreeOf[K, V](opts.Order, opts.Compare)
+	b.fill = opts.FillFactor
+	b.duplicates = opts.Duplicates
+	return b, nil
+}
+
+// TryInsert is Insert reporting an error matching ErrDuplicateKey when the
+// tree rejects duplicates and key is already there
+func (b *BtreeOf[K, V]) TryInsert(key K, value V) error {
+	switch b.duplicates {
+	case ReplaceDuplicates:
+		b.Put(key, value)
+		return nil
+	case RejectDuplicates:
+		if _, found := b.Get(key); found {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, key)
+		}
+	}
+	b.add(key, value)
+	return nil
+}
+
+// TryGet is Get reporting an error matching ErrKeyNotFound for a missing
+// key
+func (b *BtreeOf[K, V]) TryGet(key K) (V, error) {
+	value, found := b.Get(key)
+	if !found {
+		return value, fmt.Errorf("%w: %v", ErrKeyNotFound, key)
+	}
+	return value, nil
+}
+
+// TryRemove is Remove returning the removed value, or an error matching
+// ErrKeyNotFound for a missing key
+func (b *BtreeOf[K, V]) TryRemove(key K) (V, error) {
+	value, removed := b.delete(key)
+	if !removed {
+		return value, fmt.Errorf("%w: %v", ErrKeyNotFound, key)
+	}
+	b.emitDelete(key, value)
+	return value, nil
+}
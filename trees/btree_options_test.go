@@ -0,0 +1,197 @@
+package trees
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestNewBtreeWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     BtreeOptionsOf[int]
+		expected error
+	}{
+		{"defaults", BtreeOptionsOf[int]{Order: 3, Compare: cmp.Compare[int]}, nil},
+		{"all set", BtreeOptionsOf[int]{Order: 8, Compare: cmp.Compare[int], FillFactor: 0.7, Duplicates: RejectDuplicates}, nil},
+		{"order too small", BtreeOptionsOf[int]{Order: 2, Compare: cmp.Compare[int]}, ErrInvalidOrder},
+		{"negative order", BtreeOptionsOf[int]{Order: -1, Compare: cmp.Compare[int]}, ErrInvalidOrder},
+		{"no compare", BtreeOptionsOf[int]{Order: 4}, ErrInvalidOptions},
+		{"negative fill", BtreeOptionsOf[int]{Order: 4, Compare: cmp.Compare[int], FillFactor: -0.5}, ErrInvalidOptions},
+		{"overfull", BtreeOptionsOf[int]{Order: 4, Compare: cmp.Compare[int], FillFactor: 1.5}, ErrInvalidOptions},
+		{"NaN fill", BtreeOptionsOf[int]{Order: 4, Compare: cmp.Compare[int], FillFactor: math.NaN()}, ErrInvalidOptions},
+		{"unknown policy", BtreeOptionsOf[int]{Order: 4, Compare: cmp.Compare[int], Duplicates: 7}, ErrInvalidOptions},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := NewBtreeWithOptionsOf[int, any](tc.opts)
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("got error %v, want %v", err, tc.expected)
+			}
+			if (b == nil) != (tc.expected != nil) {
+				t.Errorf("got tree %v with error %v", b, err)
+			}
+		})
+	}
+
+	// the int variant orders keys as numbers by default
+	b, err := NewBtreeWithOptions(BtreeOptions{Order: 4})
+	if err != nil {
+		t.Fatalf("NewBtreeWithOptions: %v", err)
+	}
+	for _, key := range []int{3, 1, 2} {
+		b.Insert(key, nil)
+	}
+	if got := b.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("keys = %v, want [1 2 3]", got)
+	}
+
+	// NewBtree still fixes up the order
+	if NewBtree(1).order != 3 {
+		t.Errorf("NewBtree(1) should use order 3")
+	}
+}
+
+func TestBtree_DuplicatePolicy(t *testing.T) {
+	newTree := func(policy DuplicatePolicy) *Btree {
+		b, err := NewBtreeWithOptions(BtreeOptions{Order: 3, Duplicates: policy})
+		if err != nil {
+			t.Fatalf("NewBtreeWithOptions: %v", err)
+		}
+		for i := 0; i < 20; i++ {
+			b.Insert(i, i)
+		}
+		return b
+	}
+
+	allow := newTree(AllowDuplicates)
+	allow.Insert(5, "again")
+	if allow.Len() != 21 {
+		t.Errorf("AllowDuplicates: Len() = %d, want 21", allow.Len())
+	}
+
+	replace := newTree(ReplaceDuplicates)
+	var events []Event
+	replace.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+	replace.Insert(5, "again")
+	if err := replace.TryInsert(6, "again"); err != nil {
+		t.Errorf("ReplaceDuplicates: TryInsert returned %v", err)
+	}
+	if v, _ := replace.Get(5); replace.Len() != 20 || v != "again" {
+		t.Errorf("ReplaceDuplicates: Len() = %d and 5 holds %v, want 20 and again", replace.Len(), v)
+	}
+	if len(events) != 2 || events[0].Kind != EventUpdate || events[0].Old != 5 {
+		t.Errorf("ReplaceDuplicates: events = %v, want updates", events)
+	}
+
+	reject := newTree(RejectDuplicates)
+	reject.Insert(5, "again")
+	if err := reject.TryInsert(6, "again"); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("RejectDuplicates: TryInsert returned %v, want ErrDuplicateKey", err)
+	}
+	if err := reject.TryInsert(20, 20); err != nil {
+		t.Errorf("RejectDuplicates: TryInsert of a new key returned %v", err)
+	}
+	if v, _ := reject.Get(5); reject.Len() != 21 || v != 5 {
+		t.Errorf("RejectDuplicates: Len() = %d and 5 holds %v, want 21 and 5", reject.Len(), v)
+	}
+	checkBtree(t, allow)
+	checkBtree(t, replace)
+	checkBtree(t, reject)
+
+	t.Run("BulkLoad", func(t *testing.T) {
+		entries := func(yield func(int, any) bool) {
+			for _, key := range []int{30, 3, 30, 25} {
+				if !yield(key, -key) {
+					return
+				}
+			}
+		}
+		replace.BulkLoad(entries)
+		if v, _ := replace.Get(3); replace.Len() != 22 || v != -3 {
+			t.Errorf("ReplaceDuplicates: Len() = %d and 3 holds %v, want 22 and -3", replace.Len(), v)
+		}
+		checkBtree(t, replace)
+
+		before := btreeEntries(reject)
+		if err := reject.BulkLoadContext(t.Context(), entries); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("RejectDuplicates: BulkLoadContext returned %v, want ErrDuplicateKey", err)
+		}
+		if !reflect.DeepEqual(btreeEntries(reject), before) {
+			t.Errorf("RejectDuplicates: failed BulkLoadContext changed the tree")
+		}
+
+		b, _ := NewBtreeWithOptions(BtreeOptions{Order: 3, Duplicates: RejectDuplicates})
+		b.Insert(1, "a")
+		if err := b.BulkLoad(maps.All(map[int]any{1: "x", 2: "y", 3: "z"})); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("RejectDuplicates: BulkLoad returned %v, want ErrDuplicateKey", err)
+		}
+		if got := b.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1}) {
+			t.Errorf("RejectDuplicates: keys after failed BulkLoad = %v, want [1]", got)
+		}
+	})
+}
+
+func TestBtree_TryGetRemove(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 2, 3)
+	if v, err := b.TryGet(2); v != 20 || err != nil {
+		t.Errorf("TryGet(2) = %v, %v, want 20 and nil", v, err)
+	}
+	if _, err := b.TryGet(4); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("TryGet(4) returned %v, want ErrKeyNotFound", err)
+	}
+	if v, err := b.TryRemove(2); v != 20 || err != nil {
+		t.Errorf("TryRemove(2) = %v, %v, want 20 and nil", v, err)
+	}
+	if _, err := b.TryRemove(2); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("second TryRemove(2) returned %v, want ErrKeyNotFound", err)
+	}
+	checkBtree(t, b)
+}
+
+func TestBtree_FillFactor(t *testing.T) {
+	leaves := func(b *Btree) int {
+		count := 0
+		var walk func(node *BtreeNode)
+		walk = func(node *BtreeNode) {
+			if node.isLeaf {
+				count++
+			}
+			for _, child := range node.children {
+				walk(child)
+			}
+		}
+		walk(b.root)
+		return count
+	}
+
+	var counts []int
+	for _, fill := range []float64{0, 1, 0.75, 0.5, 0.1} {
+		b, err := NewBtreeWithOptions(BtreeOptions{Order: 17, FillFactor: fill})
+		if err != nil {
+			t.Fatalf("NewBtreeWithOptions: %v", err)
+		}
+		b.BulkLoad(func(yield func(int, any) bool) {
+			for i := 0; i < 10000 && yield(i, i); i++ {
+			}
+		})
+		checkBtree(t, b)
+		counts = append(counts, leaves(b))
+	}
+
+	// lower fill factors build more leaves until the minimum fill stops
+	// them, half full takes about twice as many as full
+	if counts[0] != counts[1] {
+		t.Errorf("FillFactor 0 and 1 built %d and %d leaves, want the same", counts[0], counts[1])
+	}
+	if !slices.IsSorted(counts[1:]) || counts[3] < 2*counts[1]-counts[1]/4 {
+		t.Errorf("leaf counts for falling fill factors = %v, want them rising", counts)
+	}
+}
@@ -0,0 +1,128 @@
+package trees
+
+import (
+	"context"
+	"iter"
+	"sort"
+)
+
+// Min returns the entry with the smallest key
+func (b *BtreeOf[K, V]) Min() (K, V, bool) {
+	if b.root == nil {
+		var key K
+		var value V
+		return key, value, false
+	}
+	node := b.root
+	for !node.isLeaf {
+		node = node.children[0]
+	}
+	return node.keys[0], node.values[0], true
+}
+
+// Max returns the entry with the largest key
+func (b *BtreeOf[K, V]) Max() (K, V, bool) {
+	if b.root == nil {
+		var key K
+		var value V
+		return key, value, false
+	}
+	node := b.root
+	for !node.isLeaf {
+		node = node.children[len(node.children)-1]
+	}
+	last := len(node.keys) - 1
+	return node.keys[last], node.values[last], true
+}
+
+// Floor returns the entry with the largest key <= key
+func (b *BtreeOf[K, V]) Floor(key K) (K, V, bool) {
+	var foundKey K
+	var foundVal V
+	found := false
+	for node := b.root; node != nil; {
+		// first key > key, everything before it is a candidate
+		idx := sort.Search(len(node.keys), func(i int) bool {
+			return b.compare(node.keys[i], key) > 0
+		})
+		if idx > 0 {
+			foundKey, foundVal, found = node.keys[idx-1], node.values[idx-1], true
+		}
+		if node.isLeaf {
+			break
+		}
+		node = node.children[idx]
+	}
+	return foundKey, foundVal, found
+}
+
+// Ceiling returns the entry with the smallest key >= key
+func (b *BtreeOf[K, V]) Ceiling(key K) (K, V, bool) {
+	var foundKey K
+	var foundVal V
+	found := false
+	for node := b.root; node != nil; {
+		idx := sort.Search(len(node.keys), func(i int) bool {
+			return b.compare(node.keys[i], key) >= 0
+		})
+		if idx < len(node.keys) {
+			foundKey, foundVal, found = node.keys[idx], node.values[idx], true
+		}
+		if node.isLeaf {
+			break
+		}
+		node = node.children[idx]
+	}
+	return foundKey, foundVal, found
+}
+
+// All yields every entry in key order
+func (b *BtreeOf[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		b.ascend(b.root, yield)
+	}
+}
+
+// Range yields the entries with lo <= key < hi in key order
+func (b *BtreeOf[K, V]) Range(lo K, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		b.ascendFrom(b.root, lo, func(key K) bool {
+			return b.compare(key, hi) >= 0
+		}, yield)
+	}
+}
+
+// RangeContext calls fn for the entries with lo <= key < hi in key order
+// until fn returns false or ctx is done, in which case it returns ctx.Err()
+func (b *BtreeOf[K, V]) RangeContext(ctx context.Context, lo K, hi K, fn func(key K, value V) bool) error {
+	check := ctxChecker{ctx: ctx}
+	var err error
+	b.ascendFrom(b.root, lo, func(key K) bool {
+		return b.compare(key, hi) >= 0
+	}, func(key K, value V) bool {
+		if err = check.err(); err != nil {
+			return false
+		}
+		return fn(key, value)
+	})
+	return err
+}
+
+// ascendFrom is ascend starting at the first key >= lo and ending at the
+// first key stop returns true for, it only descends into children that can
+// hold keys in range
+func (b *BtreeOf[K, V]) ascendFrom(node *BtreeNodeOf[K, V], lo K, stop func(key K) bool, fn func(key K, value V) bool) bool {
+	var buf [16]ascendFrame[K, V]
+	stack := buf[:0]
+	for node != nil {
+		idx := sort.Search(len(node.keys), func(i int) bool {
+			return b.compare(node.keys[i], lo) >= 0
+		})
+		stack = append(stack, ascendFrame[K, V]{node: node, next: idx})
+		if node.isLeaf {
+			break
+		}
+		node = node.children[idx]
+	}
+	return ascendStack(stack, stop, fn)
+}
@@ -0,0 +1,41 @@
+package trees
+
+import (
+	"bytes"
+	"iter"
+	"reflect"
+	"strings"
+)
+
+// ScanPrefix yields the entries whose keys start with prefix in key order.
+// It seeks to prefix and stops at the first key past it, so it relies on
+// compare ordering keys lexicographically, as strings.Compare and
+// bytes.Compare do. Prefixes only exist for keys whose underlying type is a
+// string or a byte slice, keys of any other type have to be equal to prefix
+// to match.
+func (b *BtreeOf[K, V]) ScanPrefix(prefix K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		b.ascendFrom(b.root, prefix, func(key K) bool {
+			return !b.hasPrefix(key, prefix)
+		}, yield)
+	}
+}
+
+func (b *BtreeOf[K, V]) hasPrefix(key K, prefix K) bool {
+	switch k := any(key).(type) {
+	case string:
+		return strings.HasPrefix(k, any(prefix).(string))
+	case []byte:
+		return bytes.HasPrefix(k, any(prefix).([]byte))
+	}
+
+	// named types such as type ID string
+	k, p := reflect.ValueOf(key), reflect.ValueOf(prefix)
+	switch {
+	case k.Kind() == reflect.String:
+		return strings.HasPrefix(k.String(), p.String())
+	case k.Kind() == reflect.Slice && k.Type().Elem().Kind() == reflect.Uint8:
+		return bytes.HasPrefix(k.Bytes(), p.Bytes())
+	}
+	return b.compare(key, prefix) == 0
+}
@@ -0,0 +1,106 @@
+package trees
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBtree_ScanPrefixStrings(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		b := NewBtreeOf[string, any](order, strings.Compare)
+		for _, key := range []string{"app", "apple", "applet", "apply", "apt", "ap", "b", "", "apple"} {
+			b.Insert(key, len(key))
+		}
+
+		tests := []struct {
+			prefix string
+			want   []string
+		}{
+			{"app", []string{"app", "apple", "apple", "applet", "apply"}},
+			{"apple", []string{"apple", "apple", "applet"}},
+			{"ap", []string{"ap", "app", "apple", "apple", "applet", "apply", "apt"}},
+			{"", []string{"", "ap", "app", "apple", "apple", "applet", "apply", "apt", "b"}},
+			{"appz", nil},
+			{"c", nil},
+		}
+		for _, tt := range tests {
+			var got []string
+			for key, val := range b.ScanPrefix(tt.prefix) {
+				if val != len(key) {
+					t.Errorf("order %d: ScanPrefix(%q) yielded %q with value %v", order, tt.prefix, key, val)
+				}
+				got = append(got, key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order %d: ScanPrefix(%q) = %v, want %v", order, tt.prefix, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestBtree_ScanPrefixBytes(t *testing.T) {
+	b := NewBtreeOf[[]byte, any](4, bytes.Compare)
+	for i := 0; i < 500; i++ {
+		b.Insert([]byte{byte(i % 5), byte(i / 5), 0xff}, i)
+	}
+
+	count := 0
+	for key, val := range b.ScanPrefix([]byte{3}) {
+		if key[0] != 3 || val.(int)%5 != 3 {
+			t.Fatalf("ScanPrefix([3]) yielded %v => %v", key, val)
+		}
+		count++
+	}
+	if count != 100 {
+		t.Errorf("ScanPrefix([3]) yielded %d entries, want 100", count)
+	}
+
+	for range b.ScanPrefix([]byte{3}) {
+		break
+	}
+	for range b.ScanPrefix([]byte{9}) {
+		t.Fatalf("ScanPrefix([9]) should yield nothing")
+	}
+}
+
+func TestBtree_ScanPrefixNamedTypes(t *testing.T) {
+	type ID string
+	ids := NewBtreeOf[ID, any](3, func(a, b ID) int { return strings.Compare(string(a), string(b)) })
+	for _, id := range []ID{"user-1", "user-12", "user-2", "group-1", "users"} {
+		ids.Insert(id, nil)
+	}
+	var got []ID
+	for id := range ids.ScanPrefix("user-") {
+		got = append(got, id)
+	}
+	if !reflect.DeepEqual(got, []ID{"user-1", "user-12", "user-2"}) {
+		t.Errorf("ScanPrefix(user-) on a named string type = %v, want [user-1 user-12 user-2]", got)
+	}
+
+	type Raw []byte
+	raws := NewBtreeOf[Raw, any](3, func(a, b Raw) int { return bytes.Compare(a, b) })
+	for _, raw := range []Raw{{1, 2}, {1, 2, 3}, {1, 3}, {2}} {
+		raws.Insert(raw, nil)
+	}
+	count := 0
+	for range raws.ScanPrefix(Raw{1, 2}) {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("ScanPrefix([1 2]) on a named byte slice type yielded %d entries, want 2", count)
+	}
+}
+
+func TestBtree_ScanPrefixInts(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 5, 5, 5, 7, 9)
+
+	var got []int
+	for key := range b.ScanPrefix(5) {
+		got = append(got, key)
+	}
+	if !reflect.DeepEqual(got, []int{5, 5, 5}) {
+		t.Errorf("ScanPrefix(5) on int keys = %v, want [5 5 5]", got)
+	}
+}
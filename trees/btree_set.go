@@ -0,0 +1,108 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+)
+
+// BtreeSetOf is a B-tree that only stores keys. It is built on a tree with
+// struct{} values, so nodes carry no value storage and splits, merges and
+// borrows only move keys. Unlike BtreeOf every key is stored at most once.
+type BtreeSetOf[K any] struct {
+	tree *BtreeOf[K, struct{}]
+}
+
+type BtreeSet = BtreeSetOf[int]
+
+func NewBtreeSet(order int) *BtreeSet {
+	return NewBtreeSetOf(order, cmp.Compare[int])
+}
+
+func NewBtreeSetOf[K any](order int, compare func(a K, b K) int) *BtreeSetOf[K] {
+	return &BtreeSetOf[K]{tree: NewBtreeOf[K, struct{}](order, compare)}
+}
+
+// Add adds key to the set and reports whether it was not there yet
+func (s *BtreeSetOf[K]) Add(key K) bool {
+	if s.Contains(key) {
+		return false
+	}
+	s.tree.Insert(key, struct{}{})
+	return true
+}
+
+// Insert is Add without the result, so BtreeSet satisfies OrderedSet
+func (s *BtreeSetOf[K]) Insert(key K) {
+	s.Add(key)
+}
+
+func (s *BtreeSetOf[K]) Contains(key K) bool {
+	_, found := s.tree.Get(key)
+	return found
+}
+
+// Delete removes key from the set and reports whether it was there
+func (s *BtreeSetOf[K]) Delete(key K) bool {
+	return s.tree.Remove(key)
+}
+
+// Remove is the same as Delete, so BtreeSet satisfies OrderedSet
+func (s *BtreeSetOf[K]) Remove(key K) bool {
+	return s.Delete(key)
+}
+
+func (s *BtreeSetOf[K]) Len() int {
+	return s.tree.Len()
+}
+
+func (s *BtreeSetOf[K]) IsEmpty() bool {
+	return s.tree.IsEmpty()
+}
+
+func (s *BtreeSetOf[K]) Clear() {
+	s.tree.Clear()
+}
+
+func (s *BtreeSetOf[K]) Min() (K, bool) {
+	key, _, found := s.tree.Min()
+	return key, found
+}
+
+func (s *BtreeSetOf[K]) Max() (K, bool) {
+	key, _, found := s.tree.Max()
+	return key, found
+}
+
+// Floor returns the largest key <= key
+func (s *BtreeSetOf[K]) Floor(key K) (K, bool) {
+	key, _, found := s.tree.Floor(key)
+	return key, found
+}
+
+// Ceiling returns the smallest key >= key
+func (s *BtreeSetOf[K]) Ceiling(key K) (K, bool) {
+	key, _, found := s.tree.Ceiling(key)
+	return key, found
+}
+
+// All yields every key in order
+func (s *BtreeSetOf[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range s.tree.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Range yields the keys with lo <= key < hi in order
+func (s *BtreeSetOf[K]) Range(lo K, hi K) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range s.tree.Range(lo, hi) {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package trees
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBtreeSet_AddContainsDelete(t *testing.T) {
+	s := NewBtreeSet(3)
+	for _, key := range []int{5, 1, 9, 5, 3, 1} {
+		s.Add(key)
+	}
+	if s.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", s.Len())
+	}
+	if s.Add(9) {
+		t.Errorf("Add(9) should report false for a key already in the set")
+	}
+	if !s.Add(7) {
+		t.Errorf("Add(7) should report true for a new key")
+	}
+	for _, key := range []int{1, 3, 5, 7, 9} {
+		if !s.Contains(key) {
+			t.Errorf("Contains(%d) should be true", key)
+		}
+	}
+	if s.Contains(4) {
+		t.Errorf("Contains(4) should be false")
+	}
+
+	if !s.Delete(5) || s.Delete(5) || s.Contains(5) {
+		t.Errorf("Delete(5) should remove the key once")
+	}
+	if got := slices.Collect(s.All()); !reflect.DeepEqual(got, []int{1, 3, 7, 9}) {
+		t.Errorf("All() = %v", got)
+	}
+	if got := slices.Collect(s.Range(2, 9)); !reflect.DeepEqual(got, []int{3, 7}) {
+		t.Errorf("Range(2, 9) = %v", got)
+	}
+	if key, ok := s.Floor(8); !ok || key != 7 {
+		t.Errorf("Floor(8) = %d, %v", key, ok)
+	}
+	if key, ok := s.Ceiling(8); !ok || key != 9 {
+		t.Errorf("Ceiling(8) = %d, %v", key, ok)
+	}
+
+	s.Clear()
+	if !s.IsEmpty() {
+		t.Errorf("set should be empty after Clear")
+	}
+	if _, ok := s.Min(); ok {
+		t.Errorf("Min() on an empty set should report false")
+	}
+}
+
+func TestBtreeSet_Strings(t *testing.T) {
+	s := NewBtreeSetOf(4, strings.Compare)
+	words := strings.Fields("the quick brown fox jumps over the lazy dog")
+	for _, word := range words {
+		s.Add(word)
+	}
+	slices.Sort(words)
+	words = slices.Compact(words)
+	if got := slices.Collect(s.All()); !reflect.DeepEqual(got, words) {
+		t.Errorf("All() = %v, want %v", got, words)
+	}
+}
+
+// BenchmarkBtreeSetMemory compares the memory a set and a map of the same
+// keys take to build
+func BenchmarkBtreeSetMemory(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(1 << 16)
+	for _, order := range []int{4, 32} {
+		b.Run(fmt.Sprintf("Btree/order=%d", order), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bt := NewBtree(order)
+				for _, key := range keys {
+					bt.Insert(key, nil)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("BtreeSet/order=%d", order), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s := NewBtreeSet(order)
+				for _, key := range keys {
+					s.Add(key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package trees
+
+import (
+	"iter"
+	"reflect"
+)
+
+// The set operations walk both trees side by side in key order and build
+// the result bottom up, so they run in O(n+m) no matter how the trees
+// overlap. The result is set up like the receiver, other may use any
+// order. Keys that repeat are matched up one to one, and are then kept only
+// if the receiver allows duplicates.
+
+// Union returns a tree holding the entries of both trees. For keys present
+// in both, merge picks the value given first the value in b and then the
+// one in other, a nil merge keeps the value from b.
+func (b *BtreeOf[K, V]) Union(other *BtreeOf[K, V], merge func(key K, mine V, theirs V) V) *BtreeOf[K, V] {
+	return b.combine(other, func(key K, aVal V, bVal V, inA bool, inB bool) (V, bool) {
+		if inA && inB {
+			return mergeValues(merge, key, aVal, bVal), true
+		}
+		if inA {
+			return aVal, true
+		}
+		return bVal, true
+	})
+}
+
+// Intersection returns a tree holding the keys present in both trees with
+// values picked by merge as in Union, a nil merge keeps the value from b.
+func (b *BtreeOf[K, V]) Intersection(other *BtreeOf[K, V], merge func(key K, mine V, theirs V) V) *BtreeOf[K, V] {
+	return b.combine(other, func(key K, aVal V, bVal V, inA bool, inB bool) (V, bool) {
+		if inA && inB {
+			return mergeValues(merge, key, aVal, bVal), true
+		}
+		var zero V
+		return zero, false
+	})
+}
+
+// Difference returns a tree holding the entries of b whose keys are not in
+// other.
+func (b *BtreeOf[K, V]) Difference(other *BtreeOf[K, V]) *BtreeOf[K, V] {
+	return b.combine(other, func(key K, aVal V, bVal V, inA bool, inB bool) (V, bool) {
+		return aVal, inA && !inB
+	})
+}
+
+// SymmetricDifference returns a tree holding the entries whose keys are in
+// exactly one of the trees.
+func (b *BtreeOf[K, V]) SymmetricDifference(other *BtreeOf[K, V]) *BtreeOf[K, V] {
+	return b.combine(other, func(key K, aVal V, bVal V, inA bool, inB bool) (V, bool) {
+		if inA && inB {
+			var zero V
+			return zero, false
+		}
+		if inA {
+			return aVal, true
+		}
+		return bVal, true
+	})
+}
+
+// IsSubset reports whether every key of b is also in other. Values are not
+// compared.
+func (b *BtreeOf[K, V]) IsSubset(other *BtreeOf[K, V]) bool {
+	if b.size > other.size {
+		return false
+	}
+	subset := true
+	mergeEntries(b, other, func(key K, aVal V, bVal V, inA bool, inB bool) bool {
+		subset = !inA || inB
+		return subset
+	})
+	return subset
+}
+
+// Equal reports whether both trees hold the same entries. equal compares
+// the values of a key, a nil equal uses reflect.DeepEqual like Diff.
+func (b *BtreeOf[K, V]) Equal(other *BtreeOf[K, V], equal func(x V, y V) bool) bool {
+	if b.size != other.size {
+		return false
+	}
+	if equal == nil {
+		equal = func(x V, y V) bool { return reflect.DeepEqual(x, y) }
+	}
+	same := true
+	mergeEntries(b, other, func(key K, aVal V, bVal V, inA bool, inB bool) bool {
+		same = inA && inB && equal(aVal, bVal)
+		return same
+	})
+	return same
+}
+
+// SameKeys reports whether both trees hold the same keys. It is Equal for
+// trees used as sets, values are not compared.
+func (b *BtreeOf[K, V]) SameKeys(other *BtreeOf[K, V]) bool {
+	return b.size == other.size && b.IsSubset(other)
+}
+
+func mergeValues[K any, V any](merge func(key K, mine V, theirs V) V, key K, mine V, theirs V) V {
+	if merge == nil {
+		return mine
+	}
+	return merge(key, mine, theirs)
+}
+
+// combine builds a tree configured like b from the entries keep accepts.
+// Unless b allows duplicates, a key accepted again is handled the way Insert
+// would, either replacing the earlier value or being dropped.
+func (b *BtreeOf[K, V]) combine(other *BtreeOf[K, V], keep func(key K, aVal V, bVal V, inA bool, inB bool) (V, bool)) *BtreeOf[K, V] {
+	var keys []K
+	var values []V
+	mergeEntries(b, other, func(key K, aVal V, bVal V, inA bool, inB bool) bool {
+		value, ok := keep(key, aVal, bVal, inA, inB)
+		if !ok {
+			return true
+		}
+		if n := len(keys); n > 0 && b.duplicates != AllowDuplicates && b.compare(keys[n-1], key) == 0 {
+			if b.duplicates == ReplaceDuplicates {
+				values[n-1] = value
+			}
+			return true
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+
+	result := b.subtree(nil, 0)
+	result.cow = &copyOnWrite{}
+	result.root, result.height = result.buildSorted(keys, values)
+	result.size = len(keys)
+	return result
+}
+
+// mergeEntries calls fn for the entries of a and b in key order until it
+// returns false. Keys found in both trees are reported in a single call
+// with inA and inB set. Both trees are walked lazily, so stopping early
+// stops the walk.
+func mergeEntries[K any, V any](a *BtreeOf[K, V], b *BtreeOf[K, V], fn func(key K, aVal V, bVal V, inA bool, inB bool) bool) {
+	next, stop := iter.Pull2(b.All())
+	defer stop()
+
+	var zero V
+	bKey, bVal, inB := next()
+	for aKey, aVal := range a.All() {
+		for inB && a.compare(bKey, aKey) < 0 {
+			if !fn(bKey, zero, bVal, false, true) {
+				return
+			}
+			bKey, bVal, inB = next()
+		}
+		if inB && a.compare(bKey, aKey) == 0 {
+			if !fn(aKey, aVal, bVal, true, true) {
+				return
+			}
+			bKey, bVal, inB = next()
+			continue
+		}
+		if !fn(aKey, aVal, zero, true, false) {
+			return
+		}
+	}
+	for inB {
+		if !fn(bKey, zero, bVal, false, true) {
+			return
+		}
+		bKey, bVal, inB = next()
+	}
+}
@@ -0,0 +1,261 @@
+package trees
+
+import (
+	"cmp"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func btreeEntries(b *Btree) map[int]any {
+	entries := map[int]any{}
+	b.ascend(b.root, func(key int, value any) bool {
+		entries[key] = value
+		return true
+	})
+	return entries
+}
+
+func TestBtree_SetOperations(t *testing.T) {
+	a := NewBtree(3)
+	for _, key := range []int{1, 3, 5, 7, 9, 11} {
+		a.Insert(key, "a")
+	}
+	b := NewBtree(6)
+	for _, key := range []int{3, 4, 5, 6, 11, 12} {
+		b.Insert(key, "b")
+	}
+	both := func(key int, x any, y any) any {
+		return x.(string) + y.(string)
+	}
+
+	tests := []struct {
+		name     string
+		result   *Btree
+		expected map[int]any
+	}{
+		{
+			name:   "union",
+			result: a.Union(b, both),
+			expected: map[int]any{
+				1: "a", 3: "ab", 4: "b", 5: "ab", 6: "b", 7: "a", 9: "a", 11: "ab", 12: "b",
+			},
+		},
+		{
+			name:     "union without merge keeps receiver values",
+			result:   a.Union(b, nil),
+			expected: map[int]any{1: "a", 3: "a", 4: "b", 5: "a", 6: "b", 7: "a", 9: "a", 11: "a", 12: "b"},
+		},
+		{
+			name:     "intersection",
+			result:   a.Intersection(b, both),
+			expected: map[int]any{3: "ab", 5: "ab", 11: "ab"},
+		},
+		{
+			name:     "difference",
+			result:   a.Difference(b),
+			expected: map[int]any{1: "a", 7: "a", 9: "a"},
+		},
+		{
+			name:     "reverse difference",
+			result:   b.Difference(a),
+			expected: map[int]any{4: "b", 6: "b", 12: "b"},
+		},
+		{
+			name:     "symmetric difference",
+			result:   a.SymmetricDifference(b),
+			expected: map[int]any{1: "a", 4: "b", 6: "b", 7: "a", 9: "a", 12: "b"},
+		},
+		{
+			name:     "intersection with empty tree",
+			result:   a.Intersection(NewBtree(3), both),
+			expected: map[int]any{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := btreeEntries(tc.result); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %v, want %v", got, tc.expected)
+			}
+			if tc.result.Len() != len(tc.expected) {
+				t.Errorf("Len() got %d, want %d", tc.result.Len(), len(tc.expected))
+			}
+			checkBtree(t, tc.result)
+		})
+	}
+
+	// the inputs must not change
+	if got := a.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 3, 5, 7, 9, 11}) {
+		t.Errorf("receiver changed by set operations: %v", got)
+	}
+	if got := b.GetKeysInOrder(); !reflect.DeepEqual(got, []int{3, 4, 5, 6, 11, 12}) {
+		t.Errorf("other changed by set operations: %v", got)
+	}
+}
+
+func TestBtree_SetOperations_Large(t *testing.T) {
+	evens := NewBtree(4)
+	threes := NewBtree(5)
+	for i := 0; i < 3000; i++ {
+		if i%2 == 0 {
+			evens.Insert(i, i)
+		}
+		if i%3 == 0 {
+			threes.Insert(i, i)
+		}
+	}
+
+	union := evens.Union(threes, nil)
+	intersection := evens.Intersection(threes, nil)
+	checkBtree(t, union)
+	checkBtree(t, intersection)
+
+	if union.Len() != 2000 {
+		t.Errorf("union Len() got %d, want 2000", union.Len())
+	}
+	if intersection.Len() != 500 {
+		t.Errorf("intersection Len() got %d, want 500", intersection.Len())
+	}
+	if !intersection.IsSubset(evens) || !intersection.IsSubset(threes) {
+		t.Errorf("intersection is not a subset of its inputs")
+	}
+	if !evens.IsSubset(union) || !threes.IsSubset(union) {
+		t.Errorf("inputs are not subsets of their union")
+	}
+}
+
+func TestBtree_SetOperations_Duplicates(t *testing.T) {
+	a, _ := NewBtreeWithOptions(BtreeOptions{Order: 3, Duplicates: RejectDuplicates})
+	a.Insert(1, "a")
+	a.Insert(5, "a")
+	b := NewBtree(3)
+	for _, key := range []int{5, 5, 9} {
+		b.Insert(key, "b")
+	}
+
+	union := a.Union(b, nil)
+	if got := union.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 5, 9}) {
+		t.Errorf("RejectDuplicates: union keys got %v, want [1 5 9]", got)
+	}
+	if v, _ := union.Get(5); v != "a" {
+		t.Errorf("RejectDuplicates: union holds %v at 5, want a", v)
+	}
+	checkBtree(t, union)
+
+	a.duplicates = ReplaceDuplicates
+	union = a.Union(b, nil)
+	if got := union.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 5, 9}) {
+		t.Errorf("ReplaceDuplicates: union keys got %v, want [1 5 9]", got)
+	}
+	if v, _ := union.Get(5); v != "b" {
+		t.Errorf("ReplaceDuplicates: union holds %v at 5, want b", v)
+	}
+	checkBtree(t, union)
+}
+
+func TestBtree_SetOperations_CloneIsolation(t *testing.T) {
+	for _, free := range []*FreeList{nil, NewFreeList(16)} {
+		ops := map[string]func(a, x *Btree) *Btree{
+			"union":                func(a, x *Btree) *Btree { return a.Union(x, nil) },
+			"intersection":         func(a, x *Btree) *Btree { return a.Intersection(x, nil) },
+			"difference":           func(a, x *Btree) *Btree { return a.Difference(x) },
+			"symmetric difference": func(a, x *Btree) *Btree { return a.SymmetricDifference(x) },
+		}
+		for name, op := range ops {
+			a := newBtreeWithKeys(3)
+			if free != nil {
+				a.SetFreeList(free)
+			}
+			for i := 0; i < 100; i++ {
+				a.Insert(i, i)
+			}
+			x := newBtreeWithKeys(3, 1, 2, 3)
+
+			// the result must not own the nodes of a it gets joined with
+			result := op(a, x)
+			result.DeleteRange(0, 1000)
+			snap := a.Clone()
+			want := btreeEntries(snap)
+			result.Insert(1000, 0)
+			result.Join(a)
+			for i := 0; i < 100; i += 2 {
+				result.Remove(i)
+			}
+			checkBtree(t, snap)
+			if got := btreeEntries(snap); !reflect.DeepEqual(got, want) {
+				t.Errorf("freelist %v: %s: snapshot changed by its result", free != nil, name)
+			}
+		}
+	}
+}
+
+func TestBtree_IsSubsetStopsEarly(t *testing.T) {
+	compares := 0
+	compare := func(x, y int) int {
+		compares++
+		return cmp.Compare(x, y)
+	}
+	a, b := NewBtreeOf[int, any](8, compare), NewBtreeOf[int, any](8, compare)
+	for i := 0; i < 1000; i++ {
+		a.Insert(i, nil)
+		b.Insert(i+1, nil)
+	}
+
+	compares = 0
+	if a.IsSubset(b) || a.SameKeys(b) {
+		t.Fatalf("trees with different first keys reported as subset")
+	}
+	if compares > 10 {
+		t.Errorf("IsSubset and SameKeys compared %d keys, should stop at the first missing one", compares)
+	}
+}
+
+func TestBtree_IsSubsetAndSameKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		a, b           []int
+		expectedSubset bool
+		expectedSame   bool
+	}{
+		{"both empty", nil, nil, true, true},
+		{"empty is subset", nil, []int{1}, true, false},
+		{"equal", []int{1, 2, 3}, []int{3, 2, 1}, true, true},
+		{"proper subset", []int{1, 3}, []int{1, 2, 3}, true, false},
+		{"superset", []int{1, 2, 3}, []int{1, 3}, false, false},
+		{"disjoint", []int{1, 2}, []int{3, 4}, false, false},
+		{"same size different keys", []int{1, 2, 5}, []int{1, 2, 3}, false, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// different orders on purpose, the shapes of the trees must not matter
+			a := newBtreeWithKeys(3, tc.a...)
+			b := newBtreeWithKeys(7, tc.b...)
+			if got := a.IsSubset(b); got != tc.expectedSubset {
+				t.Errorf("IsSubset() got %v, want %v", got, tc.expectedSubset)
+			}
+			if got := a.SameKeys(b); got != tc.expectedSame {
+				t.Errorf("SameKeys() got %v, want %v", got, tc.expectedSame)
+			}
+			if got := a.Equal(b, nil); got != tc.expectedSame {
+				t.Errorf("Equal() got %v, want %v", got, tc.expectedSame)
+			}
+		})
+	}
+
+	a, b := NewBtree(3), NewBtree(3)
+	a.Insert(1, "a")
+	b.Insert(1, "b")
+	if !a.SameKeys(b) {
+		t.Errorf("SameKeys() should not compare values")
+	}
+	if a.Equal(b, nil) {
+		t.Errorf("Equal() should compare values")
+	}
+	ignoreCase := func(x, y any) bool { return strings.EqualFold(x.(string), y.(string)) }
+	b.Put(1, "A")
+	if !a.Equal(b, ignoreCase) {
+		t.Errorf("Equal() should compare values with the given function")
+	}
+}
@@ -1,6 +1,8 @@
 package trees
 
 import (
+	"fmt"
+	"math/rand"
 	"reflect"
 	"testing"
 )
@@ -151,6 +153,30 @@ func TestBtree_Insert_Splits(t *testing.T) {
 	}
 }
 
+func TestBtree_Put(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		b := NewBtree(order)
+		b.SetMonoid(NewMonoid(0,
+			func(key int, value any) any { return value.(int) },
+			func(a any, b any) any { return a.(int) + b.(int) },
+		))
+		for i := 0; i < 200; i++ {
+			b.Put(i%50, i)
+		}
+		checkBtree(t, b)
+		if b.Len() != 50 {
+			t.Errorf("order %d: Len() = %d after putting 50 distinct keys, want 50", order, b.Len())
+		}
+		if val, _ := b.Get(7); val != 157 {
+			t.Errorf("order %d: Get(7) = %v, want the last value put 157", order, val)
+		}
+		// the sum summary has to follow replaced values
+		if got := b.Fold(0, 50); got != 150*50+49*50/2 {
+			t.Errorf("order %d: Fold(0, 50) = %v after replacing values", order, got)
+		}
+	}
+}
+
 func TestBtree_DepthMethods(t *testing.T) {
 	t.Run("Empty tree", func(t *testing.T) {
 		b := NewBtree(3)
@@ -179,3 +205,156 @@ func TestBtree_DepthMethods(t *testing.T) {
 		}
 	})
 }
+
+func TestBtree_LenClearIsEmpty(t *testing.T) {
+	b := NewBtree(3)
+	if !b.IsEmpty() || b.Len() != 0 {
+		t.Fatalf("new tree: expected empty with Len 0, got IsEmpty=%v Len=%d", b.IsEmpty(), b.Len())
+	}
+
+	keys := []int{10, 20, 5, 15, 25, 3, 30, 10, 10}
+	for _, key := range keys {
+		b.Insert(key, key*10)
+	}
+	if b.Len() != len(keys) {
+		t.Errorf("Len() after inserts (with duplicates): expected %d, got %d", len(keys), b.Len())
+	}
+
+	if b.Remove(1000) {
+		t.Errorf("Remove(1000): expected false for non-existent key")
+	}
+	if b.Len() != len(keys) {
+		t.Errorf("Len() after failed remove: expected %d, got %d", len(keys), b.Len())
+	}
+
+	if !b.Remove(10) {
+		t.Errorf("Remove(10): expected true")
+	}
+	if b.Len() != len(keys)-1 {
+		t.Errorf("Len() after removing one duplicate: expected %d, got %d", len(keys)-1, b.Len())
+	}
+	if b.Len() != len(b.GetKeysInOrder()) {
+		t.Errorf("Len() = %d disagrees with GetKeysInOrder() length %d", b.Len(), len(b.GetKeysInOrder()))
+	}
+
+	b.Clear()
+	if !b.IsEmpty() || b.Len() != 0 || b.height != 0 {
+		t.Errorf("after Clear(): expected empty tree, got Len=%d height=%d", b.Len(), b.height)
+	}
+	if _, found := b.Get(5); found {
+		t.Errorf("Get(5) after Clear(): expected found=false")
+	}
+
+	b.Insert(1, 10)
+	if b.Len() != 1 {
+		t.Errorf("Len() after Clear() and Insert: expected 1, got %d", b.Len())
+	}
+}
+
+// checkBtree verifies the structural invariants of b: keys are ordered, every
+// non-root node holds between minKeys and maxKeys keys, all leaves sit at the
+// same depth and the cached sizes add up
+func checkBtree(t *testing.T, b *Btree) {
+	t.Helper()
+	if b.root == nil {
+		if b.height != 0 || b.size != 0 {
+			t.Fatalf("empty tree has height %d and size %d", b.height, b.size)
+		}
+		return
+	}
+
+	var check func(node *BtreeNode, depth int, isRoot bool) int
+	check = func(node *BtreeNode, depth int, isRoot bool) int {
+		if len(node.keys) > b.maxKeys {
+			t.Fatalf("node %v holds more than %d keys", node.keys, b.maxKeys)
+		}
+		if !isRoot && len(node.keys) < b.minKeys {
+			t.Fatalf("node %v holds fewer than %d keys", node.keys, b.minKeys)
+		}
+		if isRoot && len(node.keys) == 0 {
+			t.Fatalf("root holds no keys")
+		}
+		if len(node.values) != len(node.keys) {
+			t.Fatalf("node %v has %d values", node.keys, len(node.values))
+		}
+		if node.isLeaf {
+			if depth != b.height {
+				t.Fatalf("leaf %v at depth %d, want %d", node.keys, depth, b.height)
+			}
+			if node.count != len(node.keys) {
+				t.Fatalf("leaf %v has count %d", node.keys, node.count)
+			}
+			return len(node.keys)
+		}
+		if len(node.children) != len(node.keys)+1 {
+			t.Fatalf("node %v has %d children", node.keys, len(node.children))
+		}
+		count := len(node.keys)
+		for _, child := range node.children {
+			count += check(child, depth+1, false)
+		}
+		if node.count != count {
+			t.Fatalf("node %v has count %d, want %d", node.keys, node.count, count)
+		}
+		return count
+	}
+
+	if count := check(b.root, 1, true); count != b.size {
+		t.Fatalf("tree size is %d but it holds %d entries", b.size, count)
+	}
+
+	keys := b.GetKeysInOrder()
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] > keys[i] {
+			t.Fatalf("keys out of order: %v", keys)
+		}
+	}
+}
+
+// the benchmarks insert nil values, so every allocation they report comes
+// from the nodes and not from boxing values
+func BenchmarkBtreeInsert(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(1 << 16)
+	for _, order := range []int{3, 16, 64} {
+		b.Run(fmt.Sprintf("order=%d", order), func(b *testing.B) {
+			b.ReportAllocs()
+			bt := NewBtree(order)
+			for i := 0; i < b.N; i++ {
+				if i%len(keys) == 0 {
+					bt.Clear()
+				}
+				bt.Insert(keys[i%len(keys)], nil)
+			}
+		})
+	}
+}
+
+// BenchmarkBtreeChurn removes and reinserts keys in a tree of steady size,
+// which splits and merges nodes all the time
+func BenchmarkBtreeChurn(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(1 << 14)
+	for _, order := range []int{3, 16, 64} {
+		for _, pooled := range []bool{false, true} {
+			name := fmt.Sprintf("order=%d", order)
+			if pooled {
+				name += "/freelist"
+			}
+			b.Run(name, func(b *testing.B) {
+				bt := NewBtree(order)
+				if pooled {
+					bt.SetFreeList(NewFreeList(DefaultFreeListSize))
+				}
+				for _, key := range keys {
+					bt.Insert(key, nil)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					key := keys[i%len(keys)]
+					bt.Remove(key)
+					bt.Insert(key, nil)
+				}
+			})
+		}
+	}
+}
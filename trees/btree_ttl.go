@@ -0,0 +1,296 @@
+package trees
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"sync"
+	"time"
+)
+
+// TTLBtreeOf is a B-tree for use as an ordered cache. Entries can be given a
+// time to live when they are inserted. Expired entries are dropped lazily by
+// the reads that run into them and actively by Sweep or a background sweeper.
+// Unlike BtreeOf every key is stored at most once, inserting a key that is
+// already present replaces its entry. It is safe for concurrent use.
+type TTLBtreeOf[K any] struct {
+	mu      sync.Mutex
+	entries *BtreeOf[K, *ttlEntry]
+	expiry  *BtreeSetOf[ttlKey[K]] // entries with a ttl, soonest first
+	now     func() time.Time
+	onEvict func(key K, value any)
+	evicted []evictedEntry[K] // reported once the lock is released
+}
+
+type TTLBtree = TTLBtreeOf[int]
+
+var ErrInvalidInterval = errors.New("trees: sweep interval must be positive")
+
+type ttlEntry struct {
+	value   any
+	expires time.Time // zero when the entry never expires
+}
+
+type ttlKey[K any] struct {
+	expires time.Time
+	key     K
+}
+
+type evictedEntry[K any] struct {
+	key   K
+	value any
+}
+
+func NewTTLBtree(order int) *TTLBtree {
+	return NewTTLBtreeOf(order, cmp.Compare[int])
+}
+
+func NewTTLBtreeOf[K any](order int, compare func(a K, b K) int) *TTLBtreeOf[K] {
+	return &TTLBtreeOf[K]{
+		entries: NewBtreeOf[K, *ttlEntry](order, compare),
+		expiry: NewBtreeSetOf(order, func(a ttlKey[K], b ttlKey[K]) int {
+			if c := a.expires.Compare(b.expires); c != 0 {
+				return c
+			}
+			return compare(a.key, b.key)
+		}),
+		now: time.Now,
+	}
+}
+
+// SetClock replaces the clock used to decide what has expired, a nil now
+// goes back to time.Now
+func (t *TTLBtreeOf[K]) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	t.mu.Lock()
+	t.now = now
+	t.mu.Unlock()
+}
+
+// OnEvict sets a callback that runs for every entry removed because it
+// expired. Entries that are removed, replaced or cleared explicitly are not
+// reported. The callback runs after the tree is unlocked, so it may use the
+// tree.
+func (t *TTLBtreeOf[K]) OnEvict(fn func(key K, value any)) {
+	t.mu.Lock()
+	t.onEvict = fn
+	t.mu.Unlock()
+}
+
+// unlock releases the lock and reports what expired while it was held
+func (t *TTLBtreeOf[K]) unlock() {
+	evicted, onEvict := t.evicted, t.onEvict
+	t.evicted = nil
+	t.mu.Unlock()
+	if onEvict != nil {
+		for _, e := range evicted {
+			onEvict(e.key, e.value)
+		}
+	}
+}
+
+// Insert adds an entry that never expires
+func (t *TTLBtreeOf[K]) Insert(key K, value any) {
+	t.InsertWithTTL(key, value, 0)
+}
+
+// InsertWithTTL adds an entry that expires once ttl has passed, a ttl <= 0
+// means it never expires
+func (t *TTLBtreeOf[K]) InsertWithTTL(key K, value any, ttl time.Duration) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	t.remove(key)
+	e := &ttlEntry{value: value}
+	if ttl > 0 {
+		e.expires = t.now().Add(ttl)
+		t.expiry.Add(ttlKey[K]{e.expires, key})
+	}
+	t.entries.Insert(key, e)
+}
+
+// remove drops key from both trees and returns its entry
+func (t *TTLBtreeOf[K]) remove(key K) *ttlEntry {
+	e, found := t.entries.Get(key)
+	if !found {
+		return nil
+	}
+	t.entries.Remove(key)
+	if !e.expires.IsZero() {
+		t.expiry.Delete(ttlKey[K]{e.expires, key})
+	}
+	return e
+}
+
+func (t *TTLBtreeOf[K]) expired(e *ttlEntry, now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// expire removes an entry that has expired and queues it for OnEvict
+func (t *TTLBtreeOf[K]) expire(key K) {
+	e := t.remove(key)
+	t.evicted = append(t.evicted, evictedEntry[K]{key, e.value})
+}
+
+func (t *TTLBtreeOf[K]) Get(key K) (any, bool) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	e, found := t.entries.Get(key)
+	if !found {
+		return nil, false
+	}
+	if t.expired(e, t.now()) {
+		t.expire(key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// TTL returns how long the entry for key has left to live, ok is false when
+// there is no live entry and the duration is 0 when it never expires
+func (t *TTLBtreeOf[K]) TTL(key K) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	e, found := t.entries.Get(key)
+	if !found {
+		return 0, false
+	}
+	now := t.now()
+	if t.expired(e, now) {
+		t.expire(key)
+		return 0, false
+	}
+	if e.expires.IsZero() {
+		return 0, true
+	}
+	return e.expires.Sub(now), true
+}
+
+func (t *TTLBtreeOf[K]) Remove(key K) bool {
+	t.mu.Lock()
+	defer t.unlock()
+	return t.remove(key) != nil
+}
+
+// All yields every live entry in key order. The tree stays locked while the
+// loop runs, so the loop body must not use the tree. Expired entries passed
+// on the way are evicted once the loop is done.
+func (t *TTLBtreeOf[K]) All() iter.Seq2[K, any] {
+	return func(yield func(K, any) bool) {
+		t.scan(t.entries.All(), yield)
+	}
+}
+
+// Range yields the live entries with lo <= key < hi in key order, under the
+// same rules as All
+func (t *TTLBtreeOf[K]) Range(lo K, hi K) iter.Seq2[K, any] {
+	return func(yield func(K, any) bool) {
+		t.scan(t.entries.Range(lo, hi), yield)
+	}
+}
+
+func (t *TTLBtreeOf[K]) scan(entries iter.Seq2[K, *ttlEntry], yield func(K, any) bool) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	now := t.now()
+	var expired []K
+	for key, e := range entries {
+		if t.expired(e, now) {
+			expired = append(expired, key)
+			continue
+		}
+		if !yield(key, e.value) {
+			break
+		}
+	}
+	// the walk is over, so the tree can be changed now
+	for _, key := range expired {
+		t.expire(key)
+	}
+}
+
+// Sweep evicts up to limit expired entries, soonest expired first, and
+// returns how many it evicted. A limit <= 0 evicts every expired entry.
+func (t *TTLBtreeOf[K]) Sweep(limit int) int {
+	t.mu.Lock()
+	defer t.unlock()
+
+	now := t.now()
+	var due []K
+	for k := range t.expiry.All() {
+		if now.Before(k.expires) || (limit > 0 && len(due) == limit) {
+			break
+		}
+		due = append(due, k.key)
+	}
+	for _, key := range due {
+		t.expire(key)
+	}
+	return len(due)
+}
+
+// StartSweeper runs Sweep(batch) every interval in the background until the
+// returned stop function is called. Sweeping in batches bounds how long the
+// tree stays locked at a time. stop waits for a running sweep to finish.
+// It returns ErrInvalidInterval and starts nothing when interval is not
+// positive.
+func (t *TTLBtreeOf[K]) StartSweeper(interval time.Duration, batch int) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// a full batch means more may be due, keep going
+				for t.Sweep(batch) == batch && batch > 0 {
+					select {
+					case <-done:
+						return
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}, nil
+}
+
+// Len returns the number of entries, including expired ones that have not
+// been evicted yet
+func (t *TTLBtreeOf[K]) Len() int {
+	t.mu.Lock()
+	defer t.unlock()
+	return t.entries.Len()
+}
+
+func (t *TTLBtreeOf[K]) IsEmpty() bool {
+	return t.Len() == 0
+}
+
+// Clear removes every entry without reporting them to OnEvict
+func (t *TTLBtreeOf[K]) Clear() {
+	t.mu.Lock()
+	defer t.unlock()
+	t.entries.Clear()
+	t.expiry.Clear()
+}
@@ -0,0 +1,167 @@
+package trees
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock the tests move by hand
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTTLBtreeWithClock() (*TTLBtree, *fakeClock, *[]int) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	t := NewTTLBtree(3)
+	t.SetClock(clock.Now)
+	var evicted []int
+	t.OnEvict(func(key int, value any) {
+		evicted = append(evicted, key)
+	})
+	return t, clock, &evicted
+}
+
+func ttlKeys(t *TTLBtree) []int {
+	var keys []int
+	for key := range t.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestTTLBtree_LazyExpiry(t *testing.T) {
+	tree, clock, evicted := newTTLBtreeWithClock()
+	tree.Insert(1, "forever")
+	tree.InsertWithTTL(2, "short", time.Second)
+	tree.InsertWithTTL(3, "long", time.Minute)
+
+	if val, found := tree.Get(2); !found || val != "short" {
+		t.Fatalf("Get(2) before expiry = %v, %v", val, found)
+	}
+	if ttl, _ := tree.TTL(3); ttl != time.Minute {
+		t.Errorf("TTL(3) = %v, want 1m", ttl)
+	}
+	if ttl, ok := tree.TTL(1); !ok || ttl != 0 {
+		t.Errorf("TTL(1) = %v, %v, want 0, true", ttl, ok)
+	}
+
+	clock.Advance(time.Second)
+	if _, found := tree.Get(2); found {
+		t.Errorf("Get(2) after its ttl should report found=false")
+	}
+	if !reflect.DeepEqual(*evicted, []int{2}) {
+		t.Errorf("evicted = %v, want [2]", *evicted)
+	}
+	if tree.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", tree.Len())
+	}
+
+	clock.Advance(time.Hour)
+	if got := ttlKeys(tree); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("All() after expiry = %v, want [1]", got)
+	}
+	if !reflect.DeepEqual(*evicted, []int{2, 3}) {
+		t.Errorf("iteration should evict what it skips, evicted = %v", *evicted)
+	}
+}
+
+func TestTTLBtree_ReplaceAndRemove(t *testing.T) {
+	tree, clock, evicted := newTTLBtreeWithClock()
+	tree.InsertWithTTL(1, "a", time.Second)
+	tree.Insert(1, "b")
+	tree.InsertWithTTL(2, "c", time.Second)
+	tree.Remove(2)
+
+	clock.Advance(time.Minute)
+	if n := tree.Sweep(0); n != 0 {
+		t.Errorf("Sweep() evicted %d entries, replaced and removed entries should be gone from the expiry index", n)
+	}
+	if val, found := tree.Get(1); !found || val != "b" {
+		t.Errorf("Get(1) = %v, %v, want b, true", val, found)
+	}
+	if len(*evicted) != 0 {
+		t.Errorf("evicted = %v, removals and replacements are not evictions", *evicted)
+	}
+}
+
+func TestTTLBtree_SweepBatches(t *testing.T) {
+	tree, clock, evicted := newTTLBtreeWithClock()
+	for i := 0; i < 10; i++ {
+		tree.InsertWithTTL(i, i, time.Duration(10-i)*time.Second)
+	}
+	tree.Insert(100, "forever")
+
+	clock.Advance(5 * time.Second)
+	if n := tree.Sweep(2); n != 2 {
+		t.Fatalf("Sweep(2) = %d, want 2", n)
+	}
+	if !reflect.DeepEqual(*evicted, []int{9, 8}) {
+		t.Errorf("Sweep should evict the soonest expired first, evicted = %v", *evicted)
+	}
+	if n := tree.Sweep(0); n != 3 {
+		t.Errorf("Sweep(0) = %d, want 3", n)
+	}
+	if got := ttlKeys(tree); !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4, 100}) {
+		t.Errorf("All() after sweeping = %v", got)
+	}
+
+	tree.Clear()
+	if !tree.IsEmpty() || tree.Sweep(0) != 0 {
+		t.Errorf("tree should be empty after Clear")
+	}
+}
+
+func TestTTLBtree_Sweeper(t *testing.T) {
+	tree := NewTTLBtree(4)
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tree.SetClock(clock.Now)
+
+	evicted := make(chan int, 100)
+	tree.OnEvict(func(key int, value any) {
+		evicted <- key
+	})
+	for i := 0; i < 50; i++ {
+		tree.InsertWithTTL(i, i, time.Second)
+	}
+	tree.Insert(50, "forever")
+
+	if _, err := tree.StartSweeper(0, 8); !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("StartSweeper(0) returned %v, want ErrInvalidInterval", err)
+	}
+	stop, err := tree.StartSweeper(time.Millisecond, 8)
+	if err != nil {
+		t.Fatalf("StartSweeper returned %v", err)
+	}
+	defer stop()
+	clock.Advance(time.Second)
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 50; i++ {
+		select {
+		case <-evicted:
+		case <-timeout:
+			t.Fatalf("sweeper evicted %d of 50 entries", i)
+		}
+	}
+	stop()
+	stop()
+	if tree.Len() != 1 {
+		t.Errorf("Len() after sweeping = %d, want 1", tree.Len())
+	}
+}
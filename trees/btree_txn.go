@@ -0,0 +1,160 @@
+package trees
+
+import (
+	"errors"
+	"iter"
+)
+
+var ErrTxnDone = errors.New("trees: transaction has already been committed or rolled back")
+
+// TxnOf stages changes to a tree and applies them all at once. The changes
+// are made to a private clone of the tree, so reads through the transaction
+// see its own writes while the tree itself is untouched until Commit, which
+// installs every change in a single step. Like the tree, a transaction is
+// not safe for concurrent use. When readers share the tree with a writer,
+// Begin and Commit have to run under the writer's lock: Begin snapshots the
+// tree, which changes it, and Commit installs the result. Staging and reads
+// through the transaction only touch the snapshot.
+type TxnOf[K any, V any] struct {
+	tree *BtreeOf[K, V]
+	base *BtreeNodeOf[K, V] // the root of tree when the transaction began
+	work *BtreeOf[K, V]
+	ops  []txnOp[K, V]
+	done bool
+}
+
+type Txn = TxnOf[int, any]
+
+type txnOp[K any, V any] struct {
+	key    K
+	value  V
+	delete bool
+}
+
+// Begin starts a transaction on the tree. Like Clone it changes the tree,
+// so it must not run alongside other writes.
+func (b *BtreeOf[K, V]) Begin() *TxnOf[K, V] {
+	return &TxnOf[K, V]{
+		tree: b,
+		base: b.root,
+		work: b.Clone(),
+	}
+}
+
+// Update runs fn in a transaction and commits it when fn returns nil. If fn
+// returns an error or panics nothing is applied.
+func (b *BtreeOf[K, V]) Update(fn func(txn *TxnOf[K, V]) error) error {
+	txn := b.Begin()
+	defer txn.Rollback()
+	if err := fn(txn); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// Put stages storing value under key, replacing the value of an entry that
+// already has key
+func (t *TxnOf[K, V]) Put(key K, value V) error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.work.Put(key, value)
+	t.ops = append(t.ops, txnOp[K, V]{key: key, value: value})
+	return nil
+}
+
+// Delete stages removing an entry with key and reports whether there was one
+// to remove
+func (t *TxnOf[K, V]) Delete(key K) (bool, error) {
+	if t.done {
+		return false, ErrTxnDone
+	}
+	if !t.work.Remove(key) {
+		return false, nil
+	}
+	t.ops = append(t.ops, txnOp[K, V]{key: key, delete: true})
+	return true, nil
+}
+
+// view is what reads go to, the tree itself once the transaction is done
+func (t *TxnOf[K, V]) view() *BtreeOf[K, V] {
+	if t.done {
+		return t.tree
+	}
+	return t.work
+}
+
+// Get reads key as the tree will be once the transaction commits
+func (t *TxnOf[K, V]) Get(key K) (V, bool) {
+	return t.view().Get(key)
+}
+
+// All yields every entry as the tree will be once the transaction commits
+func (t *TxnOf[K, V]) All() iter.Seq2[K, V] {
+	return t.view().All()
+}
+
+// Range yields the entries with lo <= key < hi as the tree will be once the
+// transaction commits
+func (t *TxnOf[K, V]) Range(lo K, hi K) iter.Seq2[K, V] {
+	return t.view().Range(lo, hi)
+}
+
+func (t *TxnOf[K, V]) Len() int {
+	return t.view().Len()
+}
+
+// Commit applies every staged change to the tree. When the tree has not
+// changed since Begin the transaction's copy simply becomes the tree,
+// otherwise the staged changes are replayed on a clone of the tree in the
+// order they were made. They are also replayed when the tree has
+// subscribers, to find the old values for the events. Either way the tree
+// switches over to the result in one step and the events follow after.
+func (t *TxnOf[K, V]) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+
+	active := t.tree.feed.active()
+	result := t.work
+	var events []EventOf[K, V]
+	if active || t.tree.root != t.base {
+		result = t.tree.Clone()
+		for _, op := range t.ops {
+			if op.delete {
+				if old, removed := result.delete(op.key); removed {
+					events = append(events, EventOf[K, V]{Kind: EventDelete, Key: op.key, Old: old})
+				}
+				continue
+			}
+			event := EventOf[K, V]{Kind: EventInsert, Key: op.key, New: op.value}
+			if old, found := result.Get(op.key); found {
+				event.Kind, event.Old = EventUpdate, old
+			}
+			result.Put(op.key, op.value)
+			events = append(events, event)
+		}
+	}
+
+	t.tree.root = result.root
+	t.tree.height = result.height
+	t.tree.size = result.size
+	t.tree.cow = result.cow
+	t.work, t.ops = nil, nil
+	if active {
+		t.tree.feed.publish(events)
+	}
+	return nil
+}
+
+// Rollback drops every staged change. Rolling back a transaction that is
+// already done returns ErrTxnDone and has no effect, so it is safe to defer.
+func (t *TxnOf[K, V]) Rollback() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+	t.work, t.ops = nil, nil
+	return nil
+}
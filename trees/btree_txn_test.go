@@ -0,0 +1,173 @@
+package trees
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTxn_CommitAndReadYourWrites(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 2, 3, 4, 5)
+	txn := b.Begin()
+
+	txn.Put(2, "two")
+	txn.Put(10, "ten")
+	if _, err := txn.Delete(4); err != nil {
+		t.Fatalf("Delete(4): %v", err)
+	}
+	if removed, _ := txn.Delete(42); removed {
+		t.Errorf("Delete(42) should report false for a missing key")
+	}
+
+	if val, found := txn.Get(2); !found || val != "two" {
+		t.Errorf("txn.Get(2) = %v, %v, want the staged value", val, found)
+	}
+	if _, found := txn.Get(4); found {
+		t.Errorf("txn.Get(4) should not see the deleted key")
+	}
+	if txn.Len() != 5 {
+		t.Errorf("txn.Len() = %d, want 5", txn.Len())
+	}
+
+	// nothing reaches the tree before Commit
+	if val, _ := b.Get(2); val != 20 {
+		t.Errorf("b.Get(2) = %v before Commit, want 20", val)
+	}
+	if _, found := b.Get(10); found || b.Len() != 5 {
+		t.Errorf("the tree should be untouched before Commit")
+	}
+
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	checkBtree(t, b)
+	if got := b.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 2, 3, 5, 10}) {
+		t.Errorf("keys after Commit = %v", got)
+	}
+	if val, _ := b.Get(2); val != "two" {
+		t.Errorf("b.Get(2) = %v after Commit, want two", val)
+	}
+
+	if err := txn.Put(7, 7); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Put after Commit: expected ErrTxnDone, got %v", err)
+	}
+	if err := txn.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("second Commit: expected ErrTxnDone, got %v", err)
+	}
+	if txn.Len() != b.Len() {
+		t.Errorf("reads through a committed txn should see the tree")
+	}
+}
+
+func TestTxn_Rollback(t *testing.T) {
+	b := newBtreeWithKeys(4, 1, 2, 3)
+	txn := b.Begin()
+	txn.Put(1, "one")
+	txn.Delete(2)
+	if err := txn.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if err := txn.Rollback(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("second Rollback: expected ErrTxnDone, got %v", err)
+	}
+	if _, err := txn.Delete(3); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Delete after Rollback: expected ErrTxnDone, got %v", err)
+	}
+
+	checkBtree(t, b)
+	if got := btreeEntries(b); !reflect.DeepEqual(got, map[int]any{1: 10, 2: 20, 3: 30}) {
+		t.Errorf("entries after Rollback = %v", got)
+	}
+}
+
+func TestTxn_CommitAfterTreeChanged(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 2, 3)
+	txn := b.Begin()
+	txn.Put(1, "txn")
+	txn.Delete(3)
+	txn.Put(3, "again")
+
+	// changes made to the tree outside the transaction survive the commit
+	b.Insert(100, 1000)
+	b.Put(2, "outside")
+
+	txn.Commit()
+	checkBtree(t, b)
+	want := map[int]any{1: "txn", 2: "outside", 3: "again", 100: 1000}
+	if got := btreeEntries(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
+
+func TestTxn_Update(t *testing.T) {
+	b := newBtreeWithKeys(3, 1, 2, 3)
+	fail := errors.New("fail")
+
+	err := b.Update(func(txn *Txn) error {
+		txn.Put(4, 40)
+		txn.Delete(1)
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Errorf("Update should return the error from fn, got %v", err)
+	}
+	if got := b.GetKeysInOrder(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("a failed Update should change nothing, keys = %v", got)
+	}
+
+	func() {
+		defer func() { recover() }()
+		b.Update(func(txn *Txn) error {
+			txn.Delete(2)
+			panic("boom")
+		})
+	}()
+	if b.Len() != 3 {
+		t.Errorf("a panicking Update should change nothing")
+	}
+
+	if err := b.Update(func(txn *Txn) error {
+		txn.Put(4, 40)
+		_, err := txn.Delete(1)
+		return err
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := b.GetKeysInOrder(); !reflect.DeepEqual(got, []int{2, 3, 4}) {
+		t.Errorf("keys after Update = %v", got)
+	}
+}
+
+// a snapshot taken before a commit never sees part of it
+func TestTxn_SnapshotIsolation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	b := NewBtree(4)
+	for _, key := range rng.Perm(500) {
+		b.Put(key, 0)
+	}
+
+	for round := 1; round <= 20; round++ {
+		snapshot := b.Clone()
+		before := btreeEntries(snapshot)
+		txn := b.Begin()
+		for i := 0; i < 50; i++ {
+			key := rng.Intn(500)
+			if rng.Intn(4) == 0 {
+				txn.Delete(key)
+			} else {
+				txn.Put(key, round)
+			}
+		}
+		if round%2 == 0 {
+			b.Put(rng.Intn(500), -round)
+		}
+		txn.Commit()
+		checkBtree(t, b)
+
+		checkBtree(t, snapshot)
+		if got := btreeEntries(snapshot); !reflect.DeepEqual(got, before) {
+			t.Fatalf("round %d: the snapshot changed under a later commit", round)
+		}
+	}
+}
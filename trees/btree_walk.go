@@ -0,0 +1,102 @@
+package trees
+
+import (
+	"iter"
+	"slices"
+)
+
+// The node iterators let callers see how a tree is laid out, to render it
+// or to write it out node by node. They yield each node with its depth, the
+// root is at depth 0. Nodes may be shared with clones and must not be used
+// after the tree changes.
+
+// Keys returns a copy of the keys stored in the node, in order
+func (n *BtreeNodeOf[K, V]) Keys() []K {
+	return slices.Clone(n.keys)
+}
+
+// Values returns a copy of the values stored in the node, in key order
+func (n *BtreeNodeOf[K, V]) Values() []V {
+	return slices.Clone(n.values)
+}
+
+// Children returns the node's children, one more than it has keys, or none
+// for a leaf
+func (n *BtreeNodeOf[K, V]) Children() []*BtreeNodeOf[K, V] {
+	return slices.Clone(n.children)
+}
+
+func (n *BtreeNodeOf[K, V]) IsLeaf() bool {
+	return n.isLeaf
+}
+
+// PreOrderNodes yields every node before its children, children left to
+// right
+func (b *BtreeOf[K, V]) PreOrderNodes() iter.Seq2[int, *BtreeNodeOf[K, V]] {
+	return func(yield func(int, *BtreeNodeOf[K, V]) bool) {
+		type item struct {
+			node  *BtreeNodeOf[K, V]
+			depth int
+		}
+		var stack []item
+		if b.root != nil {
+			stack = append(stack, item{b.root, 0})
+		}
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(top.depth, top.node) {
+				return
+			}
+			for i := len(top.node.children) - 1; i >= 0; i-- {
+				stack = append(stack, item{top.node.children[i], top.depth + 1})
+			}
+		}
+	}
+}
+
+// PostOrderNodes yields every node after its children, children left to
+// right
+func (b *BtreeOf[K, V]) PostOrderNodes() iter.Seq2[int, *BtreeNodeOf[K, V]] {
+	return func(yield func(int, *BtreeNodeOf[K, V]) bool) {
+		var stack []ascendFrame[K, V] // next is the next child to visit
+		if b.root != nil {
+			stack = append(stack, ascendFrame[K, V]{node: b.root})
+		}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.next < len(top.node.children) {
+				child := top.node.children[top.next]
+				top.next++
+				stack = append(stack, ascendFrame[K, V]{node: child})
+				continue
+			}
+			node := top.node
+			stack = stack[:len(stack)-1]
+			if !yield(len(stack), node) {
+				return
+			}
+		}
+	}
+}
+
+// LevelOrderNodes yields the nodes level by level, left to right within a
+// level
+func (b *BtreeOf[K, V]) LevelOrderNodes() iter.Seq2[int, *BtreeNodeOf[K, V]] {
+	return func(yield func(int, *BtreeNodeOf[K, V]) bool) {
+		var level []*BtreeNodeOf[K, V]
+		if b.root != nil {
+			level = append(level, b.root)
+		}
+		for depth := 0; len(level) > 0; depth++ {
+			var next []*BtreeNodeOf[K, V]
+			for _, node := range level {
+				if !yield(depth, node) {
+					return
+				}
+				next = append(next, node.children...)
+			}
+			level = next
+		}
+	}
+}
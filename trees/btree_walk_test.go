@@ -0,0 +1,113 @@
+package trees
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBtree_NodeIterators(t *testing.T) {
+	b := NewBtree(3)
+	b.BulkLoad(func(yield func(int, any) bool) {
+		for i := 1; i <= 10 && yield(i, i*10); i++ {
+		}
+	})
+
+	var rendered []string
+	for depth, node := range b.LevelOrderNodes() {
+		if depth == len(rendered) {
+			rendered = append(rendered, "")
+		}
+		rendered[depth] += fmt.Sprint(node.Keys())
+	}
+	expected := []string{"[6]", "[3][9]", "[1 2][4 5][7 8][10]"}
+	if !reflect.DeepEqual(rendered, expected) {
+		t.Errorf("levels = %q, want %q", rendered, expected)
+	}
+
+	var pre, post []string
+	for depth, node := range b.PreOrderNodes() {
+		pre = append(pre, fmt.Sprint(depth, node.Keys()))
+	}
+	for depth, node := range b.PostOrderNodes() {
+		post = append(post, fmt.Sprint(depth, node.Keys()))
+	}
+	if got := strings.Join(pre, " "); got != "0 [6] 1 [3] 2 [1 2] 2 [4 5] 1 [9] 2 [7 8] 2 [10]" {
+		t.Errorf("PreOrderNodes = %s", got)
+	}
+	if got := strings.Join(post, " "); got != "2 [1 2] 2 [4 5] 1 [3] 2 [7 8] 2 [10] 1 [9] 0 [6]" {
+		t.Errorf("PostOrderNodes = %s", got)
+	}
+
+	empty := NewBtree(3)
+	for _, seq := range []iter.Seq2[int, *BtreeNode]{empty.PreOrderNodes(), empty.PostOrderNodes(), empty.LevelOrderNodes()} {
+		for range seq {
+			t.Errorf("empty tree yielded a node")
+		}
+	}
+	for _, seq := range []iter.Seq2[int, *BtreeNode]{b.PreOrderNodes(), b.PostOrderNodes(), b.LevelOrderNodes()} {
+		count := 0
+		for range seq {
+			count++
+			break
+		}
+		if count != 1 {
+			t.Errorf("iterator did not stop when asked")
+		}
+	}
+}
+
+// rebuilding a tree from its pre-order, node by node, gives back the same
+// layout
+func TestBtree_NodeIteratorsRebuild(t *testing.T) {
+	b := NewBtree(4)
+	for i := 0; i < 500; i++ {
+		b.Insert(i*37%500, i)
+	}
+
+	type layout struct {
+		keys     []int
+		values   []any
+		children []*layout
+	}
+	var stack []*layout // the nodes still missing children
+	var root *layout
+	for _, node := range b.PreOrderNodes() {
+		l := &layout{keys: node.Keys(), values: node.Values()}
+		if node.IsLeaf() != (len(node.Children()) == 0) || len(node.Values()) != len(node.Keys()) {
+			t.Fatalf("node %v is inconsistent", node.Keys())
+		}
+		if root == nil {
+			root = l
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, l)
+			if len(parent.children) == len(parent.keys)+1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if !node.IsLeaf() {
+			stack = append(stack, l)
+		}
+	}
+
+	var check func(l *layout, node *BtreeNode)
+	check = func(l *layout, node *BtreeNode) {
+		if !reflect.DeepEqual(l.keys, node.keys) || !reflect.DeepEqual(l.values, node.values) || len(l.children) != len(node.children) {
+			t.Fatalf("rebuilt node %v differs from %v", l.keys, node.keys)
+		}
+		for i, child := range node.children {
+			check(l.children[i], child)
+		}
+	}
+	check(root, b.root)
+
+	// the copies handed out do not reach into the tree
+	keys := b.root.Keys()
+	keys[0] = -1
+	if b.root.keys[0] == -1 {
+		t.Errorf("Keys returned the node's own slice")
+	}
+}
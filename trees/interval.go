@@ -0,0 +1,228 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+	"math/rand"
+)
+
+// Interval is the half-open range [Start, End)
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Overlaps reports whether the two intervals share at least one point, an
+// empty interval overlaps nothing
+func (iv Interval) Overlaps(other Interval) bool {
+	return max(iv.Start, other.Start) < min(iv.End, other.End)
+}
+
+// Contains reports whether point lies inside the interval
+func (iv Interval) Contains(point int) bool {
+	return iv.Start <= point && point < iv.End
+}
+
+func compareIntervals(a Interval, b Interval) int {
+	if c := cmp.Compare(a.Start, b.Start); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.End, b.End)
+}
+
+type IntervalNode struct {
+	interval Interval
+	value    any
+	priority int64
+	maxEnd   int // largest End in this subtree
+	size     int
+	left     *IntervalNode
+	right    *IntervalNode
+}
+
+func (n *IntervalNode) update() {
+	n.maxEnd = n.interval.End
+	n.size = 1
+	for _, child := range []*IntervalNode{n.left, n.right} {
+		if child != nil {
+			n.maxEnd = max(n.maxEnd, child.maxEnd)
+			n.size += child.size
+		}
+	}
+}
+
+// IntervalTree stores intervals ordered by start in a treap. Every node also
+// tracks the largest end below it, which lets overlap queries skip every
+// subtree that ends before the query starts.
+type IntervalTree struct {
+	root *IntervalNode
+	rng  *rand.Rand
+}
+
+// NewIntervalTree returns an interval tree drawing its balancing priorities
+// from source. A nil source, like the zero value, uses the global math/rand
+// generator.
+func NewIntervalTree(source rand.Source) *IntervalTree {
+	t := &IntervalTree{}
+	if source != nil {
+		t.rng = rand.New(source)
+	}
+	return t
+}
+
+func (t *IntervalTree) priority() int64 {
+	if t.rng == nil {
+		return rand.Int63()
+	}
+	return t.rng.Int63()
+}
+
+// intervalSplit splits the subtree into the intervals ordered before iv and
+// the rest
+func intervalSplit(node *IntervalNode, iv Interval) (*IntervalNode, *IntervalNode) {
+	if node == nil {
+		return nil, nil
+	}
+	if compareIntervals(node.interval, iv) < 0 {
+		left, right := intervalSplit(node.right, iv)
+		node.right = left
+		node.update()
+		return node, right
+	}
+	left, right := intervalSplit(node.left, iv)
+	node.left = right
+	node.update()
+	return left, node
+}
+
+func intervalMerge(left *IntervalNode, right *IntervalNode) *IntervalNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		left.right = intervalMerge(left.right, right)
+		left.update()
+		return left
+	}
+	right.left = intervalMerge(left, right.left)
+	right.update()
+	return right
+}
+
+// Insert adds the interval [start, end) with value. The same interval may be
+// stored more than once, an empty interval is stored but never overlaps
+// anything.
+func (t *IntervalTree) Insert(start int, end int, value any) {
+	node := &IntervalNode{
+		interval: Interval{Start: start, End: end},
+		value:    value,
+		priority: t.priority(),
+	}
+	node.update()
+	t.root = intervalInsert(t.root, node)
+}
+
+func intervalInsert(root *IntervalNode, node *IntervalNode) *IntervalNode {
+	if root == nil {
+		return node
+	}
+	if node.priority > root.priority {
+		node.left, node.right = intervalSplit(root, node.interval)
+		node.update()
+		return node
+	}
+	if compareIntervals(node.interval, root.interval) < 0 {
+		root.left = intervalInsert(root.left, node)
+	} else {
+		root.right = intervalInsert(root.right, node)
+	}
+	root.update()
+	return root
+}
+
+// Remove removes one copy of the interval [start, end)
+func (t *IntervalTree) Remove(start int, end int) bool {
+	var removed bool
+	t.root, removed = intervalRemove(t.root, Interval{Start: start, End: end})
+	return removed
+}
+
+func intervalRemove(node *IntervalNode, iv Interval) (*IntervalNode, bool) {
+	if node == nil {
+		return nil, false
+	}
+
+	var removed bool
+	switch c := compareIntervals(iv, node.interval); {
+	case c < 0:
+		node.left, removed = intervalRemove(node.left, iv)
+	case c > 0:
+		node.right, removed = intervalRemove(node.right, iv)
+	default:
+		return intervalMerge(node.left, node.right), true
+	}
+
+	if removed {
+		node.update()
+	}
+	return node, removed
+}
+
+// Overlapping yields every stored interval that contains point, ordered by
+// start
+func (t *IntervalTree) Overlapping(point int) iter.Seq2[Interval, any] {
+	return t.OverlappingRange(point, point+1)
+}
+
+// OverlappingRange yields every stored interval that overlaps [lo, hi),
+// ordered by start
+func (t *IntervalTree) OverlappingRange(lo int, hi int) iter.Seq2[Interval, any] {
+	return func(yield func(Interval, any) bool) {
+		query := Interval{Start: lo, End: hi}
+		var visit func(node *IntervalNode) bool
+		visit = func(node *IntervalNode) bool {
+			// nothing below ends after the query starts
+			if node == nil || node.maxEnd <= lo {
+				return true
+			}
+			if !visit(node.left) {
+				return false
+			}
+			// everything to the right starts at or after node
+			if node.interval.Start >= hi {
+				return true
+			}
+			if node.interval.Overlaps(query) && !yield(node.interval, node.value) {
+				return false
+			}
+			return visit(node.right)
+		}
+		visit(t.root)
+	}
+}
+
+// AnyOverlap reports whether any stored interval overlaps [lo, hi)
+func (t *IntervalTree) AnyOverlap(lo int, hi int) bool {
+	for range t.OverlappingRange(lo, hi) {
+		return true
+	}
+	return false
+}
+
+func (t *IntervalTree) Len() int {
+	if t.root == nil {
+		return 0
+	}
+	return t.root.size
+}
+
+func (t *IntervalTree) IsEmpty() bool {
+	return t.root == nil
+}
+
+func (t *IntervalTree) Clear() {
+	t.root = nil
+}
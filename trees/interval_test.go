@@ -0,0 +1,173 @@
+package trees
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func collectIntervals(seq func(func(Interval, any) bool)) []Interval {
+	var out []Interval
+	seq(func(iv Interval, _ any) bool {
+		out = append(out, iv)
+		return true
+	})
+	return out
+}
+
+// checkIntervalTree verifies the treap order, the heap order on priorities
+// and the max-end augmentation
+func checkIntervalTree(t *testing.T, tree *IntervalTree) {
+	t.Helper()
+	var check func(node *IntervalNode) (int, int)
+	check = func(node *IntervalNode) (int, int) {
+		if node == nil {
+			return 0, 0
+		}
+		maxEnd, size := node.interval.End, 1
+		for _, child := range []*IntervalNode{node.left, node.right} {
+			if child == nil {
+				continue
+			}
+			if child.priority > node.priority {
+				t.Fatalf("child %v has a higher priority than %v", child.interval, node.interval)
+			}
+			childMax, childSize := check(child)
+			maxEnd = max(maxEnd, childMax)
+			size += childSize
+		}
+		if node.left != nil && compareIntervals(node.left.interval, node.interval) > 0 {
+			t.Fatalf("left child %v sorts after %v", node.left.interval, node.interval)
+		}
+		if node.right != nil && compareIntervals(node.right.interval, node.interval) < 0 {
+			t.Fatalf("right child %v sorts before %v", node.right.interval, node.interval)
+		}
+		if node.maxEnd != maxEnd || node.size != size {
+			t.Fatalf("node %v has maxEnd %d and size %d, want %d and %d", node.interval, node.maxEnd, node.size, maxEnd, size)
+		}
+		return maxEnd, size
+	}
+	check(tree.root)
+}
+
+func TestIntervalTree_Overlapping(t *testing.T) {
+	tree := NewIntervalTree(rand.NewSource(1))
+	for _, iv := range []Interval{{0, 10}, {5, 8}, {12, 20}, {15, 16}, {20, 30}, {9, 9}} {
+		tree.Insert(iv.Start, iv.End, iv.Start*100+iv.End)
+	}
+	checkIntervalTree(t, tree)
+
+	tests := []struct {
+		name     string
+		lo, hi   int
+		expected []Interval
+	}{
+		{"point inside one", 2, 3, []Interval{{0, 10}}},
+		{"point inside nested", 6, 7, []Interval{{0, 10}, {5, 8}}},
+		{"end is exclusive", 10, 11, nil},
+		{"start is inclusive", 20, 21, []Interval{{20, 30}}},
+		{"range across several", 7, 16, []Interval{{0, 10}, {5, 8}, {12, 20}, {15, 16}}},
+		{"range in a gap", 10, 12, nil},
+		{"range past the end", 30, 40, nil},
+		{"range covering everything", -5, 50, []Interval{{0, 10}, {5, 8}, {12, 20}, {15, 16}, {20, 30}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := collectIntervals(tree.OverlappingRange(tc.lo, tc.hi))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("OverlappingRange(%d, %d) = %v; want %v", tc.lo, tc.hi, got, tc.expected)
+			}
+			if any := tree.AnyOverlap(tc.lo, tc.hi); any != (len(tc.expected) > 0) {
+				t.Errorf("AnyOverlap(%d, %d) = %v", tc.lo, tc.hi, any)
+			}
+			if tc.hi == tc.lo+1 {
+				if got := collectIntervals(tree.Overlapping(tc.lo)); !reflect.DeepEqual(got, tc.expected) {
+					t.Errorf("Overlapping(%d) = %v; want %v", tc.lo, got, tc.expected)
+				}
+			}
+		})
+	}
+
+	// values travel with their intervals
+	tree.Overlapping(13)(func(iv Interval, value any) bool {
+		if value != iv.Start*100+iv.End {
+			t.Errorf("interval %v carries value %v", iv, value)
+		}
+		return true
+	})
+
+	// stopping early must not panic
+	for range tree.OverlappingRange(-5, 50) {
+		break
+	}
+}
+
+func TestIntervalTree_Remove(t *testing.T) {
+	tree := NewIntervalTree(rand.NewSource(2))
+	tree.Insert(1, 5, nil)
+	tree.Insert(1, 5, nil)
+	tree.Insert(3, 9, nil)
+
+	if tree.Remove(1, 6) {
+		t.Errorf("Remove(1, 6) = true for an interval that was never inserted")
+	}
+	if !tree.Remove(1, 5) {
+		t.Errorf("Remove(1, 5) = false")
+	}
+	if tree.Len() != 2 {
+		t.Errorf("Len() = %d; want 2", tree.Len())
+	}
+	if !tree.Remove(3, 9) {
+		t.Errorf("Remove(3, 9) = false")
+	}
+	checkIntervalTree(t, tree)
+	if tree.AnyOverlap(6, 8) {
+		t.Errorf("AnyOverlap(6, 8) = true after removing [3, 9)")
+	}
+	if got := collectIntervals(tree.Overlapping(4)); !reflect.DeepEqual(got, []Interval{{1, 5}}) {
+		t.Errorf("Overlapping(4) = %v; want the remaining copy of [1, 5)", got)
+	}
+
+	tree.Clear()
+	if !tree.IsEmpty() || tree.Len() != 0 {
+		t.Errorf("tree not empty after Clear()")
+	}
+}
+
+func TestIntervalTree_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	tree := NewIntervalTree(rand.NewSource(4))
+	var stored []Interval
+	for i := 0; i < 1000; i++ {
+		start := rng.Intn(1000)
+		iv := Interval{Start: start, End: start + rng.Intn(50)}
+		tree.Insert(iv.Start, iv.End, nil)
+		stored = append(stored, iv)
+	}
+	for i := 0; i < 300; i++ {
+		idx := rng.Intn(len(stored))
+		if !tree.Remove(stored[idx].Start, stored[idx].End) {
+			t.Fatalf("Remove(%v) = false", stored[idx])
+		}
+		stored = slices.Delete(stored, idx, idx+1)
+	}
+	checkIntervalTree(t, tree)
+	slices.SortFunc(stored, compareIntervals)
+
+	for i := 0; i < 200; i++ {
+		lo := rng.Intn(1100) - 50
+		query := Interval{Start: lo, End: lo + rng.Intn(30)}
+		var expected []Interval
+		for _, iv := range stored {
+			if iv.Overlaps(query) {
+				expected = append(expected, iv)
+			}
+		}
+		got := collectIntervals(tree.OverlappingRange(query.Start, query.End))
+		if !slices.Equal(got, expected) {
+			t.Fatalf("OverlappingRange(%d, %d) = %v; want %v", query.Start, query.End, got, expected)
+		}
+	}
+}
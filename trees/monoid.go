@@ -0,0 +1,66 @@
+package trees
+
+import (
+	"reflect"
+)
+
+// MonoidOf describes a summary that trees keep for every subtree so it can be
+// queried over a key range without visiting every entry. Measure turns one
+// entry into a summary and Combine joins two summaries. Combine must be
+// associative and Identity must leave any summary unchanged, Combine is
+// always called with the left summary first.
+type MonoidOf[K any] interface {
+	Identity() any
+	Measure(key K, value any) any
+	Combine(a any, b any) any
+}
+
+// Monoid summarizes trees keyed by ints
+type Monoid = MonoidOf[int]
+
+type monoidFuncs[K any] struct {
+	identity any
+	measure  func(key K, value any) any
+	combine  func(a any, b any) any
+}
+
+// NewMonoid builds a Monoid from plain functions
+func NewMonoid[K any](identity any, measure func(key K, value any) any, combine func(a any, b any) any) MonoidOf[K] {
+	return &monoidFuncs[K]{
+		identity: identity,
+		measure:  measure,
+		combine:  combine,
+	}
+}
+
+func (m *monoidFuncs[K]) Identity() any {
+	return m.identity
+}
+
+func (m *monoidFuncs[K]) Measure(key K, value any) any {
+	return m.measure(key, value)
+}
+
+func (m *monoidFuncs[K]) Combine(a any, b any) any {
+	return m.combine(a, b)
+}
+
+// CountMonoid counts entries
+func CountMonoid() Monoid {
+	return NewMonoid(
+		0,
+		func(int, any) any { return 1 },
+		func(a any, b any) any { return a.(int) + b.(int) },
+	)
+}
+
+// sameMonoid reports whether the two trees summarize their nodes the same
+// way, so nodes can move between them without being recomputed
+func sameMonoid[K any](a MonoidOf[K], b MonoidOf[K]) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	// comparing interfaces panics when both hold the same incomparable type
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
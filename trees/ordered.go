@@ -0,0 +1,50 @@
+package trees
+
+import (
+	"iter"
+)
+
+// OrderedMap is the API shared by the trees that map keys to values, so
+// callers can swap one implementation for another. Insert stores value under
+// key; the implementations in this module keep duplicate keys, Get then
+// returns one of their values and Remove removes one of them. Range covers
+// lo <= key < hi.
+type OrderedMap[K any, V any] interface {
+	Insert(key K, value V)
+	Get(key K) (V, bool)
+	Remove(key K) bool
+	Len() int
+	IsEmpty() bool
+	Clear()
+	Min() (K, V, bool)
+	Max() (K, V, bool)
+	Floor(key K) (K, V, bool)
+	Ceiling(key K) (K, V, bool)
+	All() iter.Seq2[K, V]
+	Range(lo K, hi K) iter.Seq2[K, V]
+}
+
+// OrderedSet is the API shared by the trees that only hold keys. It follows
+// the same rules as OrderedMap.
+type OrderedSet[K any] interface {
+	Insert(key K)
+	Contains(key K) bool
+	Remove(key K) bool
+	Len() int
+	IsEmpty() bool
+	Clear()
+	Min() (K, bool)
+	Max() (K, bool)
+	Floor(key K) (K, bool)
+	Ceiling(key K) (K, bool)
+	All() iter.Seq[K]
+	Range(lo K, hi K) iter.Seq[K]
+}
+
+var (
+	_ OrderedMap[int, any] = (*Btree)(nil)
+	_ OrderedSet[int]      = (*BtreeSet)(nil)
+	_ OrderedSet[int]      = (*BST)(nil)
+	_ OrderedSet[int]      = (*Treap)(nil)
+	_ OrderedSet[int]      = (*SplayTree)(nil)
+)
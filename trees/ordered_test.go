@@ -0,0 +1,45 @@
+package trees_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"gihtub.com/dickeyy/go-ds/trees"
+	"gihtub.com/dickeyy/go-ds/trees/treetest"
+)
+
+func TestOrderedMapConformance(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		t.Run(fmt.Sprintf("Btree/order=%d", order), func(t *testing.T) {
+			treetest.TestOrderedMap(t, func() trees.OrderedMap[int, any] {
+				return trees.NewBtree(order)
+			})
+		})
+	}
+}
+
+func TestOrderedSetConformance(t *testing.T) {
+	for _, order := range []int{3, 4, 7} {
+		t.Run(fmt.Sprintf("BtreeSet/order=%d", order), func(t *testing.T) {
+			treetest.TestOrderedSet(t, func() trees.OrderedSet[int] {
+				return trees.NewBtreeSet(order)
+			})
+		})
+	}
+	t.Run("BST", func(t *testing.T) {
+		treetest.TestOrderedSet(t, func() trees.OrderedSet[int] {
+			return &trees.BST{}
+		})
+	})
+	t.Run("Treap", func(t *testing.T) {
+		treetest.TestOrderedSet(t, func() trees.OrderedSet[int] {
+			return trees.NewTreap(rand.NewSource(1))
+		})
+	})
+	t.Run("SplayTree", func(t *testing.T) {
+		treetest.TestOrderedSet(t, func() trees.OrderedSet[int] {
+			return &trees.SplayTree{}
+		})
+	})
+}
@@ -0,0 +1,317 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+	"math"
+)
+
+type SplayNode struct {
+	value int
+	left  *SplayNode
+	right *SplayNode
+}
+
+// SplayTree is a BST that moves every node it touches to the root, so keys
+// that are used often stay close to the top. That pays off when a plain BST
+// would be badly shaped, such as keys inserted in order; over random keys a
+// BST is faster on the same skewed lookups since reading it moves nothing.
+type SplayTree struct {
+	root *SplayNode
+	size int
+}
+
+// splay brings the node found by following dir to the root and returns the
+// new root. dir compares the wanted value against a node's value, -1 goes
+// left, 1 goes right and 0 stops. This is the top-down splay, it needs no
+// parent pointers and no recursion.
+func splay(root *SplayNode, dir func(value int) int) *SplayNode {
+	if root == nil {
+		return nil
+	}
+
+	// header collects the left and right trees built on the way down
+	var header SplayNode
+	l, r := &header, &header
+	c := root
+
+	for {
+		d := dir(c.value)
+		if d < 0 {
+			if c.left == nil {
+				break
+			}
+			if dir(c.left.value) < 0 {
+				// zig-zig, rotate right
+				y := c.left
+				c.left = y.right
+				y.right = c
+				c = y
+				if c.left == nil {
+					break
+				}
+			}
+			// link right
+			r.left = c
+			r = c
+			c = c.left
+		} else if d > 0 {
+			if c.right == nil {
+				break
+			}
+			if dir(c.right.value) > 0 {
+				// zig-zig, rotate left
+				y := c.right
+				c.right = y.left
+				y.left = c
+				c = y
+				if c.right == nil {
+					break
+				}
+			}
+			// link left
+			l.right = c
+			l = c
+			c = c.right
+		} else {
+			break
+		}
+	}
+
+	// reassemble
+	l.right = c.left
+	r.left = c.right
+	c.left = header.right
+	c.right = header.left
+	return c
+}
+
+func splayTo(value int) func(int) int {
+	return func(v int) int {
+		return cmp.Compare(value, v)
+	}
+}
+
+func splayMin(int) int {
+	return -1
+}
+
+func splayMax(int) int {
+	return 1
+}
+
+func (s *SplayTree) Insert(value int) {
+	newNode := &SplayNode{
+		value: value,
+	}
+	s.size++
+
+	if s.root == nil {
+		s.root = newNode
+		return
+	}
+
+	// the closest value ends up at the root, the new node goes above it
+	c := splay(s.root, splayTo(value))
+	if value < c.value {
+		newNode.left = c.left
+		newNode.right = c
+		c.left = nil
+	} else {
+		newNode.right = c.right
+		newNode.left = c
+		c.right = nil
+	}
+	s.root = newNode
+}
+
+func (s *SplayTree) Remove(value int) bool {
+	if s.root == nil {
+		return false
+	}
+
+	s.root = splay(s.root, splayTo(value))
+	if s.root.value != value {
+		return false
+	}
+
+	if s.root.left == nil {
+		s.root = s.root.right
+	} else {
+		// the largest value on the left has no right child once splayed,
+		// the right subtree hangs off it
+		right := s.root.right
+		s.root = splay(s.root.left, splayMax)
+		s.root.right = right
+	}
+	s.size--
+	return true
+}
+
+// Get looks value up and splays it, or the last node visited when it is not
+// in the tree, to the root
+func (s *SplayTree) Get(value int) *SplayNode {
+	if s.root == nil {
+		return nil
+	}
+
+	s.root = splay(s.root, splayTo(value))
+	if s.root.value != value {
+		return nil
+	}
+	return s.root
+}
+
+func (s *SplayTree) Len() int {
+	return s.size
+}
+
+func (s *SplayTree) IsEmpty() bool {
+	return s.size == 0
+}
+
+func (s *SplayTree) Clear() {
+	s.root = nil
+	s.size = 0
+}
+
+// bfs
+func (s *SplayTree) GetMinDepth() int {
+	if s.root == nil {
+		return 0
+	}
+
+	queue := []*SplayNode{s.root}
+	depth := 0
+
+	for len(queue) > 0 {
+		depth++
+		levelSize := len(queue)
+		for i := 0; i < levelSize; i++ {
+			c := queue[0]
+			queue = queue[1:]
+
+			if c.left == nil && c.right == nil {
+				return depth
+			}
+			if c.left != nil {
+				queue = append(queue, c.left)
+			}
+			if c.right != nil {
+				queue = append(queue, c.right)
+			}
+		}
+	}
+
+	return 0
+}
+
+// dfs, splay trees can be very deep between accesses so this does not recurse
+func (s *SplayTree) GetMaxDepth() int {
+	if s.root == nil {
+		return 0
+	}
+
+	type NodeDepth struct {
+		SplayNode *SplayNode
+		Depth     int
+	}
+
+	stack := []NodeDepth{{s.root, 1}}
+	maxDepth := 0
+
+	for len(stack) > 0 {
+		li := len(stack) - 1
+		c := stack[li]
+		stack = stack[:li]
+
+		maxDepth = max(maxDepth, c.Depth)
+
+		if c.SplayNode.right != nil {
+			stack = append(stack, NodeDepth{c.SplayNode.right, c.Depth + 1})
+		}
+		if c.SplayNode.left != nil {
+			stack = append(stack, NodeDepth{c.SplayNode.left, c.Depth + 1})
+		}
+	}
+
+	return maxDepth
+}
+
+// Contains reports whether value is in the tree, splaying like Get
+func (s *SplayTree) Contains(value int) bool {
+	return s.Get(value) != nil
+}
+
+// Min splays the smallest value to the root and returns it
+func (s *SplayTree) Min() (int, bool) {
+	if s.root == nil {
+		return 0, false
+	}
+	s.root = splay(s.root, splayMin)
+	return s.root.value, true
+}
+
+// Max splays the largest value to the root and returns it
+func (s *SplayTree) Max() (int, bool) {
+	if s.root == nil {
+		return 0, false
+	}
+	s.root = splay(s.root, splayMax)
+	return s.root.value, true
+}
+
+// Floor returns the largest value <= value. The search for value is
+// splayed, which leaves the answer at the root or at the top of its left
+// subtree.
+func (s *SplayTree) Floor(value int) (int, bool) {
+	if s.root == nil {
+		return 0, false
+	}
+	s.root = splay(s.root, splayTo(value))
+	if s.root.value <= value {
+		return s.root.value, true
+	}
+	return binaryMax(s.root.left)
+}
+
+// Ceiling returns the smallest value >= value, splaying like Floor
+func (s *SplayTree) Ceiling(value int) (int, bool) {
+	if s.root == nil {
+		return 0, false
+	}
+	s.root = splay(s.root, splayTo(value))
+	if s.root.value >= value {
+		return s.root.value, true
+	}
+	return binaryMin(s.root.right)
+}
+
+// All yields every value in order, iterating does not splay
+func (s *SplayTree) All() iter.Seq[int] {
+	return binaryAscend(s.root, math.MinInt, nil)
+}
+
+// Range yields the values with lo <= value < hi in order, iterating does
+// not splay
+func (s *SplayTree) Range(lo int, hi int) iter.Seq[int] {
+	return binaryRange(s.root, lo, hi)
+}
+
+// -- Helpers for Testing and Stuff --
+func (s *SplayTree) InOrderTraversal() []int {
+	result := []int{}
+	var stack []*SplayNode
+	c := s.root
+	for c != nil || len(stack) > 0 {
+		for c != nil {
+			stack = append(stack, c)
+			c = c.left
+		}
+		c = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		result = append(result, c.value)
+		c = c.right
+	}
+	return result
+}
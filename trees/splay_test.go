@@ -0,0 +1,213 @@
+package trees
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func newSplayTreeWithValues(values ...int) *SplayTree {
+	s := &SplayTree{}
+	for _, v := range values {
+		s.Insert(v)
+	}
+	return s
+}
+
+func TestSplayTree_Insert(t *testing.T) {
+	s := newSplayTreeWithValues(10, 5, 15, 3, 7, 12, 17, 5)
+
+	if got := s.InOrderTraversal(); !reflect.DeepEqual(got, []int{3, 5, 5, 7, 10, 12, 15, 17}) {
+		t.Errorf("InOrderTraversal() = %v", got)
+	}
+	if s.root.value != 5 {
+		t.Errorf("root.value = %d; want the last inserted value 5", s.root.value)
+	}
+	if s.Len() != 8 {
+		t.Errorf("Len() = %d; want 8", s.Len())
+	}
+}
+
+func TestSplayTree_Get(t *testing.T) {
+	s := newSplayTreeWithValues(10, 5, 15, 3, 7, 12, 17)
+
+	for _, v := range []int{3, 17, 10, 7} {
+		node := s.Get(v)
+		if node == nil || node.value != v {
+			t.Fatalf("Get(%d) = %v; want node with value %d", v, node, v)
+		}
+		if s.root != node {
+			t.Errorf("Get(%d) did not splay the node to the root", v)
+		}
+	}
+
+	if node := s.Get(11); node != nil {
+		t.Errorf("Get(11) = %v; want nil", node)
+	}
+	if got := s.InOrderTraversal(); !reflect.DeepEqual(got, []int{3, 5, 7, 10, 12, 15, 17}) {
+		t.Errorf("InOrderTraversal() after lookups = %v", got)
+	}
+
+	if (&SplayTree{}).Get(1) != nil {
+		t.Errorf("Get on empty tree returned a node")
+	}
+}
+
+func TestSplayTree_Remove(t *testing.T) {
+	tests := []struct {
+		name          string
+		initialValues []int
+		valueToRemove int
+		expectRemoved bool
+		expectedOrder []int
+	}{
+		{"remove from empty tree", []int{}, 10, false, []int{}},
+		{"remove non-existent", []int{10, 5, 15}, 7, false, []int{5, 10, 15}},
+		{"remove only node", []int{10}, 10, true, []int{}},
+		{"remove smallest", []int{10, 5, 15}, 5, true, []int{10, 15}},
+		{"remove largest", []int{10, 5, 15}, 15, true, []int{5, 10}},
+		{"remove middle", []int{10, 5, 15, 3, 7, 12, 17}, 10, true, []int{3, 5, 7, 12, 15, 17}},
+		{"remove one duplicate", []int{10, 5, 5, 5, 15}, 5, true, []int{5, 5, 10, 15}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSplayTreeWithValues(tt.initialValues...)
+			if removed := s.Remove(tt.valueToRemove); removed != tt.expectRemoved {
+				t.Errorf("Remove(%d) = %v; want %v", tt.valueToRemove, removed, tt.expectRemoved)
+			}
+			if got := s.InOrderTraversal(); !reflect.DeepEqual(got, tt.expectedOrder) {
+				t.Errorf("InOrderTraversal() = %v; want %v", got, tt.expectedOrder)
+			}
+			if s.Len() != len(tt.expectedOrder) {
+				t.Errorf("Len() = %d; want %d", s.Len(), len(tt.expectedOrder))
+			}
+		})
+	}
+}
+
+func TestSplayTree_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := &SplayTree{}
+	var want []int
+	for i := 0; i < 2000; i++ {
+		v := rng.Intn(500)
+		switch rng.Intn(3) {
+		case 0:
+			s.Insert(v)
+			want = append(want, v)
+		case 1:
+			idx := slices.Index(want, v)
+			if removed := s.Remove(v); removed != (idx >= 0) {
+				t.Fatalf("Remove(%d) = %v; want %v", v, removed, idx >= 0)
+			}
+			if idx >= 0 {
+				want = slices.Delete(want, idx, idx+1)
+			}
+		default:
+			if found := s.Get(v) != nil; found != slices.Contains(want, v) {
+				t.Fatalf("Get(%d) found = %v; want %v", v, found, !found)
+			}
+		}
+	}
+	slices.Sort(want)
+	if got := s.InOrderTraversal(); !slices.Equal(got, want) {
+		t.Fatalf("InOrderTraversal() = %v; want %v", got, want)
+	}
+	if s.Len() != len(want) {
+		t.Errorf("Len() = %d; want %d", s.Len(), len(want))
+	}
+}
+
+func TestSplayTree_Depth(t *testing.T) {
+	s := &SplayTree{}
+	if s.GetMinDepth() != 0 || s.GetMaxDepth() != 0 {
+		t.Errorf("depth of empty tree is not 0")
+	}
+
+	// sorted inserts leave a chain, one lookup of the deepest value roughly
+	// halves it
+	for i := 0; i < 1000; i++ {
+		s.Insert(i)
+	}
+	if depth := s.GetMaxDepth(); depth != 1000 {
+		t.Errorf("GetMaxDepth() after sorted inserts = %d; want 1000", depth)
+	}
+	s.Get(0)
+	if depth := s.GetMaxDepth(); depth > 510 {
+		t.Errorf("GetMaxDepth() after splaying the deepest value = %d", depth)
+	}
+}
+
+// zipfKeys returns n keys drawn from a zipf distribution over keys, the hot
+// keys are spread over the key space instead of all being small numbers
+func zipfKeys(keys []int, n int) []int {
+	rng := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rng, 1.5, 1, uint64(len(keys)-1))
+	out := make([]int, n)
+	for i := range out {
+		out[i] = keys[zipf.Uint64()]
+	}
+	return out
+}
+
+// zipfGets returns lookups of a zipf workload against each tree holding the
+// keys 0 to size-1 inserted in ascending order, the way ids are handed out.
+// A BST degenerates into a chain under that order while the splay tree
+// pulls the hot keys up as they are used. The trees are built once and
+// shared by every run.
+func zipfGets(size int) map[string]func(b *testing.B) {
+	keys := make([]int, size)
+	for i := range keys {
+		keys[i] = i
+	}
+	lookups := zipfKeys(rand.New(rand.NewSource(2)).Perm(size), 1<<16)
+
+	s := newSplayTreeWithValues(keys...)
+	bst := newBSTWithValues(keys...)
+	bt := NewBtree(32)
+	for _, key := range keys {
+		bt.Insert(key, key)
+	}
+	return map[string]func(b *testing.B){
+		"SplayTree": func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.Get(lookups[i&(len(lookups)-1)])
+			}
+		},
+		"BST": func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bst.Get(lookups[i&(len(lookups)-1)])
+			}
+		},
+		"Btree": func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bt.Get(lookups[i&(len(lookups)-1)])
+			}
+		},
+	}
+}
+
+func TestSplayTree_ZipfGetWins(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs benchmarks")
+	}
+	nsPerOp := map[string]int64{}
+	for name, bench := range zipfGets(20000) {
+		nsPerOp[name] = testing.Benchmark(bench).NsPerOp()
+	}
+	t.Logf("ns/op on zipf lookups: %v", nsPerOp)
+	// the BST walks a chain, the margin is orders of magnitude; the Btree is
+	// within a small factor so it is only reported
+	if nsPerOp["SplayTree"] >= nsPerOp["BST"] {
+		t.Errorf("SplayTree took %d ns/op, not faster than the BST's %d", nsPerOp["SplayTree"], nsPerOp["BST"])
+	}
+}
+
+func BenchmarkZipfGet(b *testing.B) {
+	gets := zipfGets(20000)
+	for _, name := range []string{"SplayTree", "BST", "Btree"} {
+		b.Run(name, gets[name])
+	}
+}
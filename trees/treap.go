@@ -0,0 +1,309 @@
+package trees
+
+import (
+	"iter"
+	"math"
+	"math/rand"
+)
+
+type TreapNode struct {
+	value    int
+	priority int64
+	size     int // number of values in this subtree
+	left     *TreapNode
+	right    *TreapNode
+}
+
+// Treap is a BST that stays balanced in expectation: every node gets a random
+// priority and the tree is kept heap ordered on it. Everything is built on
+// split and merge, which also makes range deletes and joins cheap.
+type Treap struct {
+	root *TreapNode
+	rng  *rand.Rand
+}
+
+// NewTreap returns a treap drawing priorities from source so tests can get
+// the same shape every run. A nil source, like the zero value Treap, uses the
+// global math/rand generator.
+func NewTreap(source rand.Source) *Treap {
+	t := &Treap{}
+	if source != nil {
+		t.rng = rand.New(source)
+	}
+	return t
+}
+
+func (t *Treap) priority() int64 {
+	if t.rng == nil {
+		return rand.Int63()
+	}
+	return t.rng.Int63()
+}
+
+func treapSize(node *TreapNode) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+func (n *TreapNode) update() {
+	n.size = 1 + treapSize(n.left) + treapSize(n.right)
+}
+
+// treapSplit splits the subtree into the values less than value and the
+// values greater than or equal to value
+func treapSplit(node *TreapNode, value int) (*TreapNode, *TreapNode) {
+	if node == nil {
+		return nil, nil
+	}
+	if node.value < value {
+		left, right := treapSplit(node.right, value)
+		node.right = left
+		node.update()
+		return node, right
+	}
+	left, right := treapSplit(node.left, value)
+	node.left = right
+	node.update()
+	return left, node
+}
+
+// treapMerge joins two subtrees where every value in left is <= every value
+// in right
+func treapMerge(left *TreapNode, right *TreapNode) *TreapNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		left.right = treapMerge(left.right, right)
+		left.update()
+		return left
+	}
+	right.left = treapMerge(left, right.left)
+	right.update()
+	return right
+}
+
+func (t *Treap) Insert(value int) {
+	node := &TreapNode{
+		value:    value,
+		priority: t.priority(),
+		size:     1,
+	}
+	t.root = treapInsert(t.root, node)
+}
+
+func treapInsert(root *TreapNode, node *TreapNode) *TreapNode {
+	if root == nil {
+		return node
+	}
+	// the new node belongs above root, everything below is split around it
+	if node.priority > root.priority {
+		node.left, node.right = treapSplit(root, node.value)
+		node.update()
+		return node
+	}
+	if node.value < root.value {
+		root.left = treapInsert(root.left, node)
+	} else {
+		root.right = treapInsert(root.right, node)
+	}
+	root.update()
+	return root
+}
+
+func (t *Treap) Remove(value int) bool {
+	var removed bool
+	t.root, removed = treapRemove(t.root, value)
+	return removed
+}
+
+func treapRemove(node *TreapNode, value int) (*TreapNode, bool) {
+	if node == nil {
+		return nil, false
+	}
+
+	var removed bool
+	if value < node.value {
+		node.left, removed = treapRemove(node.left, value)
+	} else if value > node.value {
+		node.right, removed = treapRemove(node.right, value)
+	} else {
+		// the children are already heap ordered, merging them closes the gap
+		return treapMerge(node.left, node.right), true
+	}
+
+	if removed {
+		node.update()
+	}
+	return node, removed
+}
+
+func (t *Treap) Get(value int) *TreapNode {
+	c := t.root
+	for c != nil {
+		if value == c.value {
+			return c
+		} else if value < c.value {
+			c = c.left
+		} else {
+			c = c.right
+		}
+	}
+	return nil
+}
+
+// Split cuts the treap at value. left holds the values less than value and
+// right the rest, t is left empty. Both halves keep drawing priorities from
+// the same source.
+func (t *Treap) Split(value int) (left *Treap, right *Treap) {
+	l, r := treapSplit(t.root, value)
+	t.root = nil
+	return &Treap{root: l, rng: t.rng}, &Treap{root: r, rng: t.rng}
+}
+
+// Join moves every value of other into t. The value ranges of the two treaps
+// must not overlap, other may sit either entirely above or entirely below t.
+// Join reports false and leaves both untouched when they do overlap.
+func (t *Treap) Join(other *Treap) bool {
+	if other == t || other.root == nil {
+		return other.root == nil
+	}
+
+	lo, hi := t.root, other.root
+	if lo != nil && treapMin(hi).value < treapMin(lo).value {
+		lo, hi = hi, lo
+	}
+	if lo != nil && treapMax(lo).value > treapMin(hi).value {
+		return false
+	}
+
+	t.root = treapMerge(lo, hi)
+	other.root = nil
+	return true
+}
+
+// DeleteRange removes every value with lo <= value < hi and returns how many
+// were removed. The range is cut out with two splits and dropped as a whole.
+func (t *Treap) DeleteRange(lo int, hi int) int {
+	if lo >= hi {
+		return 0
+	}
+	left, rest := treapSplit(t.root, lo)
+	mid, right := treapSplit(rest, hi)
+	t.root = treapMerge(left, right)
+	return treapSize(mid)
+}
+
+func treapMin(node *TreapNode) *TreapNode {
+	for node.left != nil {
+		node = node.left
+	}
+	return node
+}
+
+func treapMax(node *TreapNode) *TreapNode {
+	for node.right != nil {
+		node = node.right
+	}
+	return node
+}
+
+func (t *Treap) Len() int {
+	return treapSize(t.root)
+}
+
+func (t *Treap) IsEmpty() bool {
+	return t.root == nil
+}
+
+func (t *Treap) Clear() {
+	t.root = nil
+}
+
+// bfs
+func (t *Treap) GetMinDepth() int {
+	if t.root == nil {
+		return 0
+	}
+
+	queue := []*TreapNode{t.root}
+	depth := 0
+
+	for len(queue) > 0 {
+		depth++
+		levelSize := len(queue)
+		for i := 0; i < levelSize; i++ {
+			c := queue[0]
+			queue = queue[1:]
+
+			if c.left == nil && c.right == nil {
+				return depth
+			}
+			if c.left != nil {
+				queue = append(queue, c.left)
+			}
+			if c.right != nil {
+				queue = append(queue, c.right)
+			}
+		}
+	}
+
+	return 0
+}
+
+// GetMaxDepth returns the number of levels, counted level by level so a
+// badly balanced treap cannot overflow the stack
+func (t *Treap) GetMaxDepth() int {
+	depth := 0
+	binaryLevels(t.root, func(node *TreapNode, level int) bool {
+		depth = level + 1
+		return true
+	})
+	return depth
+}
+
+func (t *Treap) Contains(value int) bool {
+	return t.Get(value) != nil
+}
+
+func (t *Treap) Min() (int, bool) {
+	return binaryMin(t.root)
+}
+
+func (t *Treap) Max() (int, bool) {
+	return binaryMax(t.root)
+}
+
+// Floor returns the largest value <= value
+func (t *Treap) Floor(value int) (int, bool) {
+	return binaryFloor(t.root, value)
+}
+
+// Ceiling returns the smallest value >= value
+func (t *Treap) Ceiling(value int) (int, bool) {
+	return binaryCeiling(t.root, value)
+}
+
+// All yields every value in order
+func (t *Treap) All() iter.Seq[int] {
+	return binaryAscend(t.root, math.MinInt, nil)
+}
+
+// Range yields the values with lo <= value < hi in order
+func (t *Treap) Range(lo int, hi int) iter.Seq[int] {
+	return binaryRange(t.root, lo, hi)
+}
+
+// -- Helpers for Testing and Stuff --
+func (t *Treap) InOrderTraversal() []int {
+	result := make([]int, 0, t.Len())
+	for value := range t.All() {
+		result = append(result, value)
+	}
+	return result
+}
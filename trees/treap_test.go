@@ -0,0 +1,214 @@
+package trees
+
+import (
+	"math/rand"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"testing"
+)
+
+func newTreapWithValues(seed int64, values ...int) *Treap {
+	t := NewTreap(rand.NewSource(seed))
+	for _, v := range values {
+		t.Insert(v)
+	}
+	return t
+}
+
+// checkTreap verifies the BST order, the heap order on priorities and the
+// cached subtree sizes
+func checkTreap(t *testing.T, tr *Treap) {
+	t.Helper()
+	var check func(node *TreapNode) int
+	check = func(node *TreapNode) int {
+		if node == nil {
+			return 0
+		}
+		if node.left != nil && (node.left.value > node.value || node.left.priority > node.priority) {
+			t.Fatalf("left child %d breaks the treap order under %d", node.left.value, node.value)
+		}
+		if node.right != nil && (node.right.value < node.value || node.right.priority > node.priority) {
+			t.Fatalf("right child %d breaks the treap order under %d", node.right.value, node.value)
+		}
+		size := 1 + check(node.left) + check(node.right)
+		if node.size != size {
+			t.Fatalf("node %d has size %d, want %d", node.value, node.size, size)
+		}
+		return size
+	}
+	check(tr.root)
+	if values := tr.InOrderTraversal(); !slices.IsSorted(values) {
+		t.Fatalf("values out of order: %v", values)
+	}
+}
+
+func TestTreap_InsertGetRemove(t *testing.T) {
+	tr := newTreapWithValues(1, 10, 5, 15, 3, 7, 12, 17, 5)
+
+	if got := tr.InOrderTraversal(); !reflect.DeepEqual(got, []int{3, 5, 5, 7, 10, 12, 15, 17}) {
+		t.Errorf("InOrderTraversal() = %v", got)
+	}
+	if tr.Len() != 8 {
+		t.Errorf("Len() = %d; want 8", tr.Len())
+	}
+	checkTreap(t, tr)
+
+	for _, v := range []int{3, 5, 10, 17} {
+		if node := tr.Get(v); node == nil || node.value != v {
+			t.Errorf("Get(%d) = %v; want node with value %d", v, node, v)
+		}
+	}
+	if tr.Get(11) != nil {
+		t.Errorf("Get(11) found a value that was never inserted")
+	}
+
+	if tr.Remove(11) {
+		t.Errorf("Remove(11) = true for a missing value")
+	}
+	for _, v := range []int{5, 10, 3} {
+		if !tr.Remove(v) {
+			t.Errorf("Remove(%d) = false", v)
+		}
+		checkTreap(t, tr)
+	}
+	if got := tr.InOrderTraversal(); !reflect.DeepEqual(got, []int{5, 7, 12, 15, 17}) {
+		t.Errorf("InOrderTraversal() after removes = %v", got)
+	}
+	if tr.Len() != 5 {
+		t.Errorf("Len() after removes = %d; want 5", tr.Len())
+	}
+
+	tr.Clear()
+	if !tr.IsEmpty() || tr.Len() != 0 || tr.GetMinDepth() != 0 || tr.GetMaxDepth() != 0 {
+		t.Errorf("treap not empty after Clear()")
+	}
+}
+
+func TestTreap_ZeroValue(t *testing.T) {
+	var tr Treap
+	for i := 0; i < 100; i++ {
+		tr.Insert(i)
+	}
+	checkTreap(t, &tr)
+	if tr.Len() != 100 {
+		t.Errorf("Len() = %d; want 100", tr.Len())
+	}
+}
+
+func TestTreap_Reproducible(t *testing.T) {
+	values := rand.New(rand.NewSource(7)).Perm(500)
+	a := newTreapWithValues(42, values...)
+	b := newTreapWithValues(42, values...)
+
+	var shape func(x *TreapNode, y *TreapNode) bool
+	shape = func(x *TreapNode, y *TreapNode) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.value == y.value && x.priority == y.priority && shape(x.left, y.left) && shape(x.right, y.right)
+	}
+	if !shape(a.root, b.root) {
+		t.Errorf("treaps built from the same seed have different shapes")
+	}
+}
+
+func TestTreap_Balanced(t *testing.T) {
+	tr := NewTreap(rand.NewSource(1))
+	// sorted input makes a plain BST degenerate into a list
+	for i := 0; i < 10000; i++ {
+		tr.Insert(i)
+	}
+	checkTreap(t, tr)
+	if depth := tr.GetMaxDepth(); depth > 60 {
+		t.Errorf("GetMaxDepth() = %d for 10000 sorted inserts, expected a balanced tree", depth)
+	}
+	if depth := tr.GetMinDepth(); depth < 2 {
+		t.Errorf("GetMinDepth() = %d for 10000 sorted inserts", depth)
+	}
+}
+
+func TestTreap_Degenerate(t *testing.T) {
+	// an unlucky run of priorities can still make a chain, the read-only
+	// walks must not recurse along it
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	const n = 1_000_000
+	nodes := make([]TreapNode, n)
+	for i := range nodes {
+		nodes[i] = TreapNode{value: i, priority: int64(n - i), size: n - i}
+		if i+1 < n {
+			nodes[i].right = &nodes[i+1]
+		}
+	}
+	tr := &Treap{root: &nodes[0]}
+
+	if depth := tr.GetMaxDepth(); depth != n {
+		t.Errorf("GetMaxDepth() = %d, want %d", depth, n)
+	}
+	if values := tr.InOrderTraversal(); len(values) != n || !slices.IsSorted(values) {
+		t.Errorf("InOrderTraversal of a chain returned %d values", len(values))
+	}
+}
+
+func TestTreap_SplitJoin(t *testing.T) {
+	values := rand.New(rand.NewSource(8)).Perm(300)
+	tr := newTreapWithValues(3, values...)
+
+	left, right := tr.Split(120)
+	if !tr.IsEmpty() {
+		t.Errorf("treap not empty after Split")
+	}
+	checkTreap(t, left)
+	checkTreap(t, right)
+	if left.Len() != 120 || right.Len() != 180 {
+		t.Fatalf("Split(120) sizes %d and %d; want 120 and 180", left.Len(), right.Len())
+	}
+	if got := left.InOrderTraversal(); got[len(got)-1] != 119 {
+		t.Errorf("left half ends at %d; want 119", got[len(got)-1])
+	}
+
+	// join works with the halves in either order
+	if !right.Join(left) {
+		t.Fatalf("Join of split halves failed")
+	}
+	checkTreap(t, right)
+	if !left.IsEmpty() {
+		t.Errorf("other not empty after Join")
+	}
+	if got := right.InOrderTraversal(); !reflect.DeepEqual(got, sortedInts(300)) {
+		t.Errorf("Join of split halves = %v", got)
+	}
+
+	overlapping := newTreapWithValues(4, 50, 400)
+	if right.Join(overlapping) {
+		t.Errorf("Join of overlapping treaps succeeded")
+	}
+	if right.Len() != 300 || overlapping.Len() != 2 {
+		t.Errorf("failed Join changed the treaps")
+	}
+}
+
+func TestTreap_DeleteRange(t *testing.T) {
+	tr := newTreapWithValues(5, rand.New(rand.NewSource(9)).Perm(100)...)
+
+	if removed := tr.DeleteRange(20, 20); removed != 0 {
+		t.Errorf("DeleteRange on an empty range removed %d", removed)
+	}
+	if removed := tr.DeleteRange(20, 70); removed != 50 {
+		t.Errorf("DeleteRange(20, 70) removed %d; want 50", removed)
+	}
+	checkTreap(t, tr)
+	want := append(sortedInts(20), sortedInts(100)[70:]...)
+	if got := tr.InOrderTraversal(); !reflect.DeepEqual(got, want) {
+		t.Errorf("InOrderTraversal() after DeleteRange = %v", got)
+	}
+}
+
+func sortedInts(n int) []int {
+	values := make([]int, n)
+	for i := range values {
+		values[i] = i
+	}
+	return values
+}
@@ -0,0 +1,373 @@
+// Package treetest checks implementations of the trees.OrderedMap and
+// trees.OrderedSet interfaces against a simple model, so every tree in the
+// module is held to the same behavior.
+package treetest
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"gihtub.com/dickeyy/go-ds/trees"
+)
+
+// TestOrderedMap runs the conformance suite against maps made by newMap,
+// every call must return a new empty map
+func TestOrderedMap(t *testing.T, newMap func() trees.OrderedMap[int, any]) {
+	t.Run("Empty", func(t *testing.T) {
+		m := newMap()
+		if !m.IsEmpty() || m.Len() != 0 {
+			t.Errorf("new map: IsEmpty()=%v Len()=%d", m.IsEmpty(), m.Len())
+		}
+		if _, found := m.Get(1); found {
+			t.Errorf("Get(1) on empty map: expected found=false")
+		}
+		if m.Remove(1) {
+			t.Errorf("Remove(1) on empty map: expected false")
+		}
+		if _, _, found := m.Min(); found {
+			t.Errorf("Min() on empty map: expected found=false")
+		}
+		if _, _, found := m.Max(); found {
+			t.Errorf("Max() on empty map: expected found=false")
+		}
+		if _, _, found := m.Floor(1); found {
+			t.Errorf("Floor(1) on empty map: expected found=false")
+		}
+		if _, _, found := m.Ceiling(1); found {
+			t.Errorf("Ceiling(1) on empty map: expected found=false")
+		}
+		for key := range m.All() {
+			t.Errorf("All() on empty map yielded %d", key)
+		}
+	})
+
+	t.Run("InsertGetRemove", func(t *testing.T) {
+		m := newMap()
+		for _, key := range []int{50, 20, 80, 10, 30, 70, 90} {
+			m.Insert(key, key*10)
+		}
+		if m.Len() != 7 {
+			t.Errorf("Len() got %d, want 7", m.Len())
+		}
+		for _, key := range []int{10, 20, 30, 50, 70, 80, 90} {
+			if val, found := m.Get(key); !found || val != key*10 {
+				t.Errorf("Get(%d) got %v, %v", key, val, found)
+			}
+		}
+		if _, found := m.Get(40); found {
+			t.Errorf("Get(40): expected found=false")
+		}
+		if m.Remove(40) {
+			t.Errorf("Remove(40): expected false")
+		}
+		if !m.Remove(50) {
+			t.Errorf("Remove(50): expected true")
+		}
+		if _, found := m.Get(50); found {
+			t.Errorf("Get(50) after Remove: expected found=false")
+		}
+		if m.Len() != 6 {
+			t.Errorf("Len() after Remove got %d, want 6", m.Len())
+		}
+		m.Clear()
+		if !m.IsEmpty() {
+			t.Errorf("map not empty after Clear()")
+		}
+	})
+
+	t.Run("Ordered", func(t *testing.T) {
+		m := newMap()
+		for _, key := range []int{50, 20, 80, 10, 30, 70, 90} {
+			m.Insert(key, key*10)
+		}
+		checkMapBounds(t, m)
+
+		var keys []int
+		for key, val := range m.All() {
+			if val != key*10 {
+				t.Errorf("All() yielded %d with value %v", key, val)
+			}
+			keys = append(keys, key)
+		}
+		if !slices.Equal(keys, []int{10, 20, 30, 50, 70, 80, 90}) {
+			t.Errorf("All() got %v", keys)
+		}
+
+		keys = keys[:0]
+		for key := range m.Range(20, 80) {
+			keys = append(keys, key)
+		}
+		if !slices.Equal(keys, []int{20, 30, 50, 70}) {
+			t.Errorf("Range(20, 80) got %v", keys)
+		}
+
+		// stopping early must be safe
+		keys = keys[:0]
+		for key := range m.All() {
+			if key > 30 {
+				break
+			}
+			keys = append(keys, key)
+		}
+		if !slices.Equal(keys, []int{10, 20, 30}) {
+			t.Errorf("All() stopped early got %v", keys)
+		}
+	})
+
+	t.Run("Model", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		m := newMap()
+		model := map[int]any{}
+		for i := 0; i < 3000; i++ {
+			key := rng.Intn(500)
+			_, present := model[key]
+			switch op := rng.Intn(4); {
+			case op < 2 && !present:
+				m.Insert(key, i)
+				model[key] = i
+			case op == 2:
+				if removed := m.Remove(key); removed != present {
+					t.Fatalf("Remove(%d) got %v, want %v", key, removed, present)
+				}
+				delete(model, key)
+			default:
+				if val, found := m.Get(key); found != present || val != model[key] {
+					t.Fatalf("Get(%d) got %v, %v, want %v, %v", key, val, found, model[key], present)
+				}
+			}
+		}
+		if m.Len() != len(model) {
+			t.Fatalf("Len() got %d, want %d", m.Len(), len(model))
+		}
+
+		want := slices.Sorted(func(yield func(int) bool) {
+			for key := range model {
+				if !yield(key) {
+					return
+				}
+			}
+		})
+		var got []int
+		for key, val := range m.All() {
+			if val != model[key] {
+				t.Fatalf("All() yielded %d with value %v, want %v", key, val, model[key])
+			}
+			got = append(got, key)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("All() got %v, want %v", got, want)
+		}
+
+		for i := 0; i < 100; i++ {
+			lo := rng.Intn(550) - 25
+			hi := lo + rng.Intn(100)
+			got = got[:0]
+			for key := range m.Range(lo, hi) {
+				got = append(got, key)
+			}
+			if w := inRange(want, lo, hi); !slices.Equal(got, w) {
+				t.Fatalf("Range(%d, %d) got %v, want %v", lo, hi, got, w)
+			}
+
+			floor, _, floorFound := m.Floor(lo)
+			wantFloor, wantFloorFound := modelFloor(want, lo)
+			if floorFound != wantFloorFound || floor != wantFloor {
+				t.Fatalf("Floor(%d) got %d, %v, want %d, %v", lo, floor, floorFound, wantFloor, wantFloorFound)
+			}
+			ceiling, _, ceilingFound := m.Ceiling(lo)
+			wantCeiling, wantCeilingFound := modelCeiling(want, lo)
+			if ceilingFound != wantCeilingFound || ceiling != wantCeiling {
+				t.Fatalf("Ceiling(%d) got %d, %v, want %d, %v", lo, ceiling, ceilingFound, wantCeiling, wantCeilingFound)
+			}
+		}
+	})
+}
+
+func checkMapBounds(t *testing.T, m trees.OrderedMap[int, any]) {
+	t.Helper()
+	if key, val, _ := m.Min(); key != 10 || val != 100 {
+		t.Errorf("Min() got %d, %v", key, val)
+	}
+	if key, val, _ := m.Max(); key != 90 || val != 900 {
+		t.Errorf("Max() got %d, %v", key, val)
+	}
+	tests := []struct {
+		key                      int
+		floor, ceiling           int
+		floorFound, ceilingFound bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{40, 30, 50, true, true},
+		{90, 90, 90, true, true},
+		{95, 90, 0, true, false},
+	}
+	for _, tc := range tests {
+		key, val, found := m.Floor(tc.key)
+		if found != tc.floorFound || (found && (key != tc.floor || val != key*10)) {
+			t.Errorf("Floor(%d) got %d, %v, %v", tc.key, key, val, found)
+		}
+		key, val, found = m.Ceiling(tc.key)
+		if found != tc.ceilingFound || (found && (key != tc.ceiling || val != key*10)) {
+			t.Errorf("Ceiling(%d) got %d, %v, %v", tc.key, key, val, found)
+		}
+	}
+}
+
+// TestOrderedSet runs the conformance suite against sets made by newSet,
+// every call must return a new empty set
+func TestOrderedSet(t *testing.T, newSet func() trees.OrderedSet[int]) {
+	t.Run("Empty", func(t *testing.T) {
+		s := newSet()
+		if !s.IsEmpty() || s.Len() != 0 {
+			t.Errorf("new set: IsEmpty()=%v Len()=%d", s.IsEmpty(), s.Len())
+		}
+		if s.Contains(1) {
+			t.Errorf("Contains(1) on empty set: expected false")
+		}
+		if s.Remove(1) {
+			t.Errorf("Remove(1) on empty set: expected false")
+		}
+		if _, found := s.Min(); found {
+			t.Errorf("Min() on empty set: expected found=false")
+		}
+		if _, found := s.Max(); found {
+			t.Errorf("Max() on empty set: expected found=false")
+		}
+		if _, found := s.Floor(1); found {
+			t.Errorf("Floor(1) on empty set: expected found=false")
+		}
+		if _, found := s.Ceiling(1); found {
+			t.Errorf("Ceiling(1) on empty set: expected found=false")
+		}
+		for key := range s.All() {
+			t.Errorf("All() on empty set yielded %d", key)
+		}
+	})
+
+	t.Run("Ordered", func(t *testing.T) {
+		s := newSet()
+		for _, key := range []int{50, 20, 80, 10, 30, 70, 90} {
+			s.Insert(key)
+		}
+		if s.Len() != 7 {
+			t.Errorf("Len() got %d, want 7", s.Len())
+		}
+		if key, _ := s.Min(); key != 10 {
+			t.Errorf("Min() got %d", key)
+		}
+		if key, _ := s.Max(); key != 90 {
+			t.Errorf("Max() got %d", key)
+		}
+		if key, found := s.Floor(40); !found || key != 30 {
+			t.Errorf("Floor(40) got %d, %v", key, found)
+		}
+		if key, found := s.Ceiling(40); !found || key != 50 {
+			t.Errorf("Ceiling(40) got %d, %v", key, found)
+		}
+		if _, found := s.Floor(5); found {
+			t.Errorf("Floor(5): expected found=false")
+		}
+		if _, found := s.Ceiling(95); found {
+			t.Errorf("Ceiling(95): expected found=false")
+		}
+
+		if got := slices.Collect(s.All()); !slices.Equal(got, []int{10, 20, 30, 50, 70, 80, 90}) {
+			t.Errorf("All() got %v", got)
+		}
+		if got := slices.Collect(s.Range(20, 80)); !slices.Equal(got, []int{20, 30, 50, 70}) {
+			t.Errorf("Range(20, 80) got %v", got)
+		}
+		for key := range s.All() {
+			if key > 30 {
+				break
+			}
+		}
+
+		s.Clear()
+		if !s.IsEmpty() || s.Contains(10) {
+			t.Errorf("set not empty after Clear()")
+		}
+	})
+
+	t.Run("Model", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(2))
+		s := newSet()
+		model := map[int]bool{}
+		for i := 0; i < 3000; i++ {
+			key := rng.Intn(500)
+			switch op := rng.Intn(4); {
+			case op < 2 && !model[key]:
+				s.Insert(key)
+				model[key] = true
+			case op == 2:
+				if removed := s.Remove(key); removed != model[key] {
+					t.Fatalf("Remove(%d) got %v, want %v", key, removed, model[key])
+				}
+				delete(model, key)
+			default:
+				if found := s.Contains(key); found != model[key] {
+					t.Fatalf("Contains(%d) got %v, want %v", key, found, model[key])
+				}
+			}
+		}
+		if s.Len() != len(model) {
+			t.Fatalf("Len() got %d, want %d", s.Len(), len(model))
+		}
+
+		var want []int
+		for key := range model {
+			want = append(want, key)
+		}
+		slices.Sort(want)
+		if got := slices.Collect(s.All()); !slices.Equal(got, want) {
+			t.Fatalf("All() got %v, want %v", got, want)
+		}
+
+		for i := 0; i < 100; i++ {
+			lo := rng.Intn(550) - 25
+			hi := lo + rng.Intn(100)
+			if got, w := slices.Collect(s.Range(lo, hi)), inRange(want, lo, hi); !slices.Equal(got, w) {
+				t.Fatalf("Range(%d, %d) got %v, want %v", lo, hi, got, w)
+			}
+			floor, floorFound := s.Floor(lo)
+			if wantFloor, wantFound := modelFloor(want, lo); floorFound != wantFound || floor != wantFloor {
+				t.Fatalf("Floor(%d) got %d, %v, want %d, %v", lo, floor, floorFound, wantFloor, wantFound)
+			}
+			ceiling, ceilingFound := s.Ceiling(lo)
+			if wantCeiling, wantFound := modelCeiling(want, lo); ceilingFound != wantFound || ceiling != wantCeiling {
+				t.Fatalf("Ceiling(%d) got %d, %v, want %d, %v", lo, ceiling, ceilingFound, wantCeiling, wantFound)
+			}
+		}
+	})
+}
+
+func inRange(sorted []int, lo int, hi int) []int {
+	var out []int
+	for _, key := range sorted {
+		if key >= lo && key < hi {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func modelFloor(sorted []int, key int) (int, bool) {
+	idx, found := slices.BinarySearch(sorted, key)
+	if found {
+		return key, true
+	}
+	if idx == 0 {
+		return 0, false
+	}
+	return sorted[idx-1], true
+}
+
+func modelCeiling(sorted []int, key int) (int, bool) {
+	idx, _ := slices.BinarySearch(sorted, key)
+	if idx == len(sorted) {
+		return 0, false
+	}
+	return sorted[idx], true
+}
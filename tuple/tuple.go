@@ -0,0 +1,122 @@
+// Package tuple packs tuples of ints, strings and byte slices into byte keys
+// that sort the same way as the tuples they encode, element by element. The
+// keys can be used with a trees.BtreeOf[[]byte, V] ordered by bytes.Compare,
+// and a tuple's key is a prefix of the key of every longer tuple starting
+// with it, so ScanPrefix finds all of them.
+package tuple
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// every element starts with a code for its type, so elements of different
+// types sort by type first
+const (
+	intCode    byte = 0x01
+	bytesCode  byte = 0x02
+	stringCode byte = 0x03
+)
+
+// strings and byte slices end in 0x00, a 0x00 inside them is escaped as
+// 0x00 0xff so it still sorts above the terminator
+const (
+	terminator byte = 0x00
+	escape     byte = 0xff
+)
+
+var ErrMalformed = errors.New("tuple: malformed key")
+
+// Encode packs elems into a key. Elements must be int, string or []byte.
+func Encode(elems ...any) ([]byte, error) {
+	return Append(nil, elems...)
+}
+
+// Append packs elems onto the end of dst, so a key can be built up from a
+// prefix one element at a time
+func Append(dst []byte, elems ...any) ([]byte, error) {
+	for _, elem := range elems {
+		switch e := elem.(type) {
+		case int:
+			dst = append(dst, intCode)
+			// flipping the sign bit makes negative numbers sort first
+			dst = binary.BigEndian.AppendUint64(dst, uint64(e)^(1<<63))
+		case string:
+			dst = append(dst, stringCode)
+			dst = appendEscaped(dst, []byte(e))
+		case []byte:
+			dst = append(dst, bytesCode)
+			dst = appendEscaped(dst, e)
+		default:
+			return nil, fmt.Errorf("tuple: unsupported element type %T", elem)
+		}
+	}
+	return dst, nil
+}
+
+func appendEscaped(dst []byte, b []byte) []byte {
+	for {
+		i := bytes.IndexByte(b, terminator)
+		if i < 0 {
+			break
+		}
+		dst = append(dst, b[:i+1]...)
+		dst = append(dst, escape)
+		b = b[i+1:]
+	}
+	dst = append(dst, b...)
+	return append(dst, terminator)
+}
+
+// Decode unpacks a key made by Encode. Ints come back as int, strings as
+// string and byte slices as []byte.
+func Decode(key []byte) ([]any, error) {
+	var elems []any
+	for len(key) > 0 {
+		code := key[0]
+		key = key[1:]
+		switch code {
+		case intCode:
+			if len(key) < 8 {
+				return nil, ErrMalformed
+			}
+			elems = append(elems, int(binary.BigEndian.Uint64(key)^(1<<63)))
+			key = key[8:]
+		case stringCode, bytesCode:
+			b, rest, err := readEscaped(key)
+			if err != nil {
+				return nil, err
+			}
+			if code == stringCode {
+				elems = append(elems, string(b))
+			} else {
+				elems = append(elems, b)
+			}
+			key = rest
+		default:
+			return nil, ErrMalformed
+		}
+	}
+	return elems, nil
+}
+
+// readEscaped reads one terminated element and returns it unescaped along
+// with what follows it
+func readEscaped(key []byte) ([]byte, []byte, error) {
+	b := []byte{}
+	for {
+		i := bytes.IndexByte(key, terminator)
+		if i < 0 {
+			return nil, nil, ErrMalformed
+		}
+		b = append(b, key[:i]...)
+		if i+1 < len(key) && key[i+1] == escape {
+			b = append(b, terminator)
+			key = key[i+2:]
+			continue
+		}
+		return b, key[i+1:], nil
+	}
+}
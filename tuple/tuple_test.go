@@ -0,0 +1,126 @@
+package tuple
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	"gihtub.com/dickeyy/go-ds/trees"
+)
+
+func TestEncode_RoundTrip(t *testing.T) {
+	tests := [][]any{
+		{},
+		{0},
+		{math.MinInt, -1, 1, math.MaxInt},
+		{"", "tenant", "a\x00b", "\x00\x00"},
+		{[]byte{}, []byte{0, 0xff, 0}, []byte("id")},
+		{"acme", 1700000000, []byte{0xde, 0xad}, -42},
+	}
+	for _, elems := range tests {
+		key, err := Encode(elems...)
+		if err != nil {
+			t.Fatalf("Encode(%v): %v", elems, err)
+		}
+		got, err := Decode(key)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%v)): %v", elems, err)
+		}
+		if len(elems) == 0 {
+			elems = nil
+		}
+		if !reflect.DeepEqual(got, elems) {
+			t.Errorf("Decode(Encode(%v)) = %v", elems, got)
+		}
+	}
+}
+
+func TestEncode_Errors(t *testing.T) {
+	if _, err := Encode("ok", 1.5); err == nil {
+		t.Errorf("Encode with a float64 element should fail")
+	}
+	for _, key := range [][]byte{
+		{intCode, 0, 0},
+		{stringCode, 'a'},
+		{0x7f},
+	} {
+		if _, err := Decode(key); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decode(%v): expected ErrMalformed, got %v", key, err)
+		}
+	}
+}
+
+// compareTuples orders tuples of the same shape element by element
+func compareTuples(a []any, b []any) int {
+	for i := range min(len(a), len(b)) {
+		var c int
+		switch x := a[i].(type) {
+		case int:
+			c = cmp.Compare(x, b[i].(int))
+		case string:
+			c = cmp.Compare(x, b[i].(string))
+		case []byte:
+			c = bytes.Compare(x, b[i].([]byte))
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func TestEncode_PreservesOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randBytes := func() []byte {
+		b := make([]byte, rng.Intn(4))
+		for i := range b {
+			b[i] = []byte{0x00, 0x01, 0xfe, 0xff}[rng.Intn(4)]
+		}
+		return b
+	}
+
+	tuples := make([][]any, 500)
+	for i := range tuples {
+		tuples[i] = []any{string(randBytes()), rng.Intn(7) - 3, randBytes()}[:1+rng.Intn(3)]
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := tuples[rng.Intn(len(tuples))], tuples[rng.Intn(len(tuples))]
+		ka, _ := Encode(a...)
+		kb, _ := Encode(b...)
+		if got, want := bytes.Compare(ka, kb), compareTuples(a, b); got != want {
+			t.Fatalf("%v vs %v: keys compare %d, tuples compare %d", a, b, got, want)
+		}
+		if len(a) <= len(b) && compareTuples(a, b[:len(a)]) == 0 && !bytes.HasPrefix(kb, ka) {
+			t.Fatalf("key of %v is not a prefix of the key of %v", a, b)
+		}
+	}
+}
+
+func TestEncode_BtreeScanPrefix(t *testing.T) {
+	b := trees.NewBtreeOf[[]byte, any](4, bytes.Compare)
+	for _, tenant := range []string{"acme", "acme2", "globex"} {
+		for ts := 3; ts > 0; ts-- {
+			key, _ := Encode(tenant, ts, []byte{byte(ts)})
+			b.Insert(key, tenant)
+		}
+	}
+
+	prefix, _ := Encode("acme")
+	var stamps []int
+	for key, val := range b.ScanPrefix(prefix) {
+		elems, err := Decode(key)
+		if err != nil || val != "acme" {
+			t.Fatalf("ScanPrefix yielded %v => %v, %v", elems, val, err)
+		}
+		stamps = append(stamps, elems[1].(int))
+	}
+	if !slices.Equal(stamps, []int{1, 2, 3}) {
+		t.Errorf("ScanPrefix(acme) timestamps = %v, want [1 2 3]", stamps)
+	}
+}